@@ -0,0 +1,224 @@
+/*
+   The glyph atlas: every glyph is rasterized once per face (and position
+   inside the pixel) into one big alpha image. Text is drawn by copying the
+   glyphs out of it, instead of rasterizing the outlines again every time.
+
+       tomato.DrawText(image.Pt(10, 30), "hello", color.RGBA{255, 255, 255, 255}, face)
+
+   The point is where the baseline starts, like the Dot of a font.Drawer.
+   With the gl backend queued text is drawn on the GPU, as instanced quads
+   out of the atlas texture, if nothing is drawn over it later in the frame.
+   The rest (and everything with the Software backend) is copied on the CPU
+   with the same masks, so it looks the same in tests. Both backends blend
+   Img premultiplied over the clear color, the text on the GPU goes over that
+   like it would go over Img, also where Img is transparent or translucent
+   (up to rounding, Img has 8 bits per channel).
+   RenderText and the labels of the widgets use the atlas on the CPU.
+   Shaped text (Arabic, Hebrew, Devanagari, ...) is in it by glyph index.
+*/
+
+package tomato
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/go-text/typesetting/bidi"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const ATLAS_WIDTH int = 1024 // the atlas grows in height
+
+type glyphKey struct {
+	face  font.Face
+	r     rune            // -1 for the shaped glyphs, they go by index
+	index sfnt.GlyphIndex // in the font of face
+	sub   fixed.Point26_6 // the fraction of the dot, glyphs look different at .5 pixels
+}
+
+type atlasGlyph struct {
+	rect    image.Rectangle // in the atlas, empty for spaces
+	offset  image.Point     // of rect.Min from the dot
+	advance fixed.Int26_6
+}
+
+// a glyph of some text, ready to be copied
+type placedGlyph struct {
+	where image.Rectangle // in the destination
+	src   image.Point     // in the atlas, goes to where.Min
+}
+
+// queued with DrawText, see Window.Draw
+type textRun struct {
+	glyphs []placedGlyph
+	color  color.RGBA
+}
+
+type glyphAtlas struct {
+	lock   sync.Mutex
+	img    *image.Alpha
+	glyphs map[glyphKey]atlasGlyph
+
+	// packed in rows (shelves), left to right
+	shelfX, shelfY, shelfH int
+
+	version int // changes with every new glyph, so the gpu knows when to upload again
+}
+
+// @Memory never shrinks, the glyphs of faces that aren't used anymore stay
+var atlas = &glyphAtlas{
+	img:    image.NewAlpha(image.Rect(0, 0, ATLAS_WIDTH, 256)),
+	glyphs: make(map[glyphKey]atlasGlyph),
+}
+
+// call it with the lock
+func (a *glyphAtlas) glyph(face font.Face, r rune, index sfnt.GlyphIndex, sub fixed.Point26_6) atlasGlyph {
+	key := glyphKey{face, r, index, sub}
+	if g, ok := a.glyphs[key]; ok {
+		return g
+	}
+
+	var dr image.Rectangle
+	var mask image.Image
+	var maskp image.Point
+	var advance fixed.Int26_6
+	if r >= 0 {
+		dr, mask, maskp, advance, _ = face.Glyph(sub, r)
+	} else {
+		// the advance comes from the shaper
+		dr, mask, maskp = glyphByIndex(face, index, sub)
+	}
+	g := atlasGlyph{advance: advance, offset: dr.Min}
+	if !dr.Empty() {
+		g.rect = a.place(dr.Size())
+		draw.Draw(a.img, g.rect, mask, maskp, draw.Src)
+		a.version++
+	}
+	a.glyphs[key] = g
+	return g
+}
+
+// finds room for a glyph of size, the glyphs have a pixel between them
+func (a *glyphAtlas) place(size image.Point) image.Rectangle {
+	if size.X > ATLAS_WIDTH {
+		panic("\ntomato ERROR: a glyph is wider than the atlas (ATLAS_WIDTH), the font is way too big\n")
+	}
+	if a.shelfX+size.X > ATLAS_WIDTH {
+		a.shelfX = 0
+		a.shelfY += a.shelfH + 1
+		a.shelfH = 0
+	}
+	for a.shelfY+size.Y > a.img.Bounds().Dy() {
+		bigger := image.NewAlpha(image.Rect(0, 0, ATLAS_WIDTH, 2*a.img.Bounds().Dy()))
+		copy(bigger.Pix, a.img.Pix)
+		a.img = bigger
+	}
+	r := image.Rectangle{image.Pt(a.shelfX, a.shelfY), image.Pt(a.shelfX, a.shelfY).Add(size)}
+	a.shelfX += size.X + 1
+	a.shelfH = Max(a.shelfH, size.Y)
+	return r
+}
+
+// where the glyphs of text go, like font.Drawer.DrawString would draw them,
+// or shaped, see the shaping. Returns the dot after the text.
+// dir is the one of the paragraph, bidi.Neutral if text is all of it.
+func (a *glyphAtlas) layout(dot fixed.Point26_6, text string, face font.Face, dir bidi.Direction, glyphs []placedGlyph) ([]placedGlyph, fixed.Point26_6) {
+	shaped := shapeDir(text, face, dir)
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if shaped != nil {
+		for _, sg := range shaped.glyphs {
+			p := dot.Add(fixed.Point26_6{X: sg.x}).Add(sg.offset)
+			whole := image.Pt(p.X.Floor(), p.Y.Floor())
+			g := a.glyph(sg.face, sg.r, sg.index, p.Sub(fixed.P(whole.X, whole.Y)))
+			if !g.rect.Empty() {
+				where := g.rect.Sub(g.rect.Min).Add(whole.Add(g.offset))
+				glyphs = append(glyphs, placedGlyph{where: where, src: g.rect.Min})
+			}
+		}
+		dot.X += shaped.advance
+		return glyphs, dot
+	}
+
+	prev := rune(-1)
+	for _, r := range text {
+		if prev >= 0 {
+			dot.X += face.Kern(prev, r)
+		}
+		whole := image.Pt(dot.X.Floor(), dot.Y.Floor())
+		sub := dot.Sub(fixed.P(whole.X, whole.Y))
+		g := a.glyph(face, r, 0, sub)
+		if !g.rect.Empty() {
+			where := g.rect.Sub(g.rect.Min).Add(whole.Add(g.offset))
+			glyphs = append(glyphs, placedGlyph{where: where, src: g.rect.Min})
+		}
+		dot.X += g.advance
+		prev = r
+	}
+	return glyphs, dot
+}
+
+// Like font.Drawer.DrawString with a uniform color, through the atlas.
+// Returns the dot after the text.
+func (a *glyphAtlas) drawString(dst draw.Image, dot fixed.Point26_6, text string, face font.Face, c color.Color) fixed.Point26_6 {
+	return a.drawStringDir(dst, dot, text, face, bidi.Neutral, c)
+}
+
+// like drawString, text is a part of a paragraph going in the direction dir
+func (a *glyphAtlas) drawStringDir(dst draw.Image, dot fixed.Point26_6, text string, face font.Face, dir bidi.Direction, c color.Color) fixed.Point26_6 {
+	glyphs, dot := a.layout(dot, text, face, dir, nil)
+	a.draw(dst, glyphs, image.NewUniform(c), dst.Bounds())
+	return dot
+}
+
+// copies the glyphs inside clip
+func (a *glyphAtlas) draw(dst draw.Image, glyphs []placedGlyph, src image.Image, clip image.Rectangle) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, g := range glyphs {
+		r := g.where.Intersect(clip)
+		if r.Empty() {
+			continue
+		}
+		draw.DrawMask(dst, r, src, image.ZP, a.img, g.src.Add(r.Min.Sub(g.where.Min)), draw.Over)
+	}
+}
+
+// Queues text to be drawn with its baseline starting at dot, see the glyph atlas
+func DrawText(dot image.Point, text string, c color.RGBA, face font.Face) {
+	current.DrawText(dot, text, c, face)
+}
+
+func (w *Window) DrawText(dot image.Point, text string, c color.RGBA, face font.Face) {
+	glyphs, _ := atlas.layout(fixed.P(dot.X, dot.Y), text, face, bidi.Neutral, nil)
+	if len(glyphs) == 0 {
+		return
+	}
+	bounds := glyphs[0].where
+	for _, g := range glyphs[1:] {
+		bounds = bounds.Union(g.where)
+	}
+	w.queueText(bounds, &textRun{glyphs: glyphs, color: c})
+}
+
+// true if something after the text in the queue is drawn where it is
+// @Speed goes through the rest of the queue for every text
+func coveredLater(queue []drawOp, where image.Rectangle) bool {
+	for _, op := range queue {
+		if op.where.Overlaps(where) {
+			return true
+		}
+	}
+	return false
+}
+
+// Backends that draw text over the presented frame themselves, the gl one
+type glyphBackend interface {
+	// called before Present() with the text nothing is drawn over
+	drawGlyphs(runs []drawOp)
+}
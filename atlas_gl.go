@@ -0,0 +1,136 @@
+//go:build !headless
+
+package tomato
+
+import (
+	"github.com/go-gl/gl/v4.2-core/gl"
+)
+
+// one instance per glyph: where it goes and where it is in the atlas, in pixels, and the color
+var glyphShaderSource = `
+	#version 420
+
+	uniform vec2 screen;
+
+	in vec4 dst;
+	in vec4 src;
+	in vec4 color;
+
+	out vec2 atlasPos;
+	out vec4 glyphColor;
+
+	const vec2 corners[6] = vec2[](
+		vec2(0, 0), vec2(1, 1), vec2(0, 1),
+		vec2(0, 0), vec2(1, 0), vec2(1, 1)
+	);
+
+	void main() {
+		vec2 corner = corners[gl_VertexID];
+		vec2 pos = mix(dst.xy, dst.zw, corner);
+		atlasPos = mix(src.xy, src.zw, corner);
+		glyphColor = color;
+		gl_Position = vec4(pos.x/screen.x*2.0 - 1.0, 1.0 - pos.y/screen.y*2.0, 0.0, 1.0);
+	}
+	#define FRAGMENT_SHADER
+	#version 420
+
+	uniform sampler2D atlas;
+	in vec2 atlasPos;
+	in vec4 glyphColor;
+
+	out vec4 outputColor;
+
+	void main() {
+		float coverage = texelFetch(atlas, ivec2(atlasPos), 0).r;
+		// premultiplied, like the gui texture
+		outputColor = glyphColor*coverage;
+	}
+`
+
+const glyphFloats = 12 // per instance
+
+func (b *glBackend) glyphSetup() error {
+	var err error
+	b.glyphShader, err = NewGLProgram(glyphShaderSource)
+	if err != nil {
+		return err
+	}
+	gl.UseProgram(b.glyphShader)
+	gl.Uniform1i(gl.GetUniformLocation(b.glyphShader, gl.Str("atlas\x00")), 0)
+	gl.BindFragDataLocation(b.glyphShader, 0, gl.Str("outputColor\x00"))
+
+	gl.GenVertexArrays(1, &b.glyphVAO)
+	gl.BindVertexArray(b.glyphVAO)
+	gl.GenBuffers(1, &b.glyphVBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.glyphVBO)
+	for i, name := range []string{"dst", "src", "color"} {
+		attrib := uint32(gl.GetAttribLocation(b.glyphShader, gl.Str(name+"\x00")))
+		gl.EnableVertexAttribArray(attrib)
+		gl.VertexAttribPointerWithOffset(attrib, 4, gl.FLOAT, false, glyphFloats*4, uintptr(i*4*4))
+		gl.VertexAttribDivisor(attrib, 1)
+	}
+
+	gl.GenTextures(1, &b.atlasTexture)
+	gl.BindTexture(gl.TEXTURE_2D, b.atlasTexture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	b.atlasVersion = -1
+	return nil
+}
+
+// the instances for Present(), clipped here so the shader doesn't have to
+func (b *glBackend) drawGlyphs(runs []drawOp) {
+	b.glyphData = b.glyphData[:0]
+	if len(runs) == 0 {
+		return
+	}
+
+	atlas.lock.Lock()
+	defer atlas.lock.Unlock()
+	for _, op := range runs {
+		// premultiplied, the shader blends like the gui texture
+		cr, cg, cb, ca := glColor(op.text.color)
+		for _, g := range op.text.glyphs {
+			r := g.where.Intersect(op.where)
+			if r.Empty() {
+				continue
+			}
+			src := g.src.Add(r.Min.Sub(g.where.Min))
+			b.glyphData = append(b.glyphData,
+				float32(r.Min.X), float32(r.Min.Y), float32(r.Max.X), float32(r.Max.Y),
+				float32(src.X), float32(src.Y), float32(src.X+r.Dx()), float32(src.Y+r.Dy()),
+				cr, cg, cb, ca)
+		}
+	}
+	if len(b.glyphData) == 0 {
+		return
+	}
+
+	b.makeContextCurrent()
+	if b.atlasVersion != atlas.version {
+		// @Speed the whole atlas, also for one new glyph
+		size := atlas.img.Bounds().Size()
+		gl.BindTexture(gl.TEXTURE_2D, b.atlasTexture)
+		gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R8, int32(size.X), int32(size.Y), 0, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(atlas.img.Pix))
+		b.atlasVersion = atlas.version
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.glyphVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, len(b.glyphData)*4, gl.Ptr(b.glyphData), gl.STREAM_DRAW)
+}
+
+// called by Present() after the gui texture, with blending on
+func (b *glBackend) presentGlyphs() {
+	instances := len(b.glyphData) / glyphFloats
+	if instances == 0 {
+		return
+	}
+	size := b.w.Img.Bounds().Size()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.UseProgram(b.glyphShader)
+	gl.Uniform2f(gl.GetUniformLocation(b.glyphShader, gl.Str("screen\x00")), float32(size.X), float32(size.Y))
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, b.atlasTexture)
+	gl.BindVertexArray(b.glyphVAO)
+	gl.DrawArraysInstanced(gl.TRIANGLES, 0, 6, int32(instances))
+}
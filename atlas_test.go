@@ -0,0 +1,76 @@
+package tomato
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func maxDiff(a, b *image.RGBA) int {
+	worst := 0
+	for i := range a.Pix {
+		d := int(a.Pix[i]) - int(b.Pix[i])
+		worst = max(worst, d, -d)
+	}
+	return worst
+}
+
+// the atlas draws like a font.Drawer, also at fractions of a pixel
+func TestAtlasLikeDrawer(t *testing.T) {
+	c := color.RGBA{200, 40, 40, 255}
+	for _, face := range []font.Face{Font("mono", 13), Font("sans-italic", 20)} {
+		for _, dot := range []fixed.Point26_6{fixed.P(3, 20), {X: fixed.I(3) + 20, Y: fixed.I(20)}, {X: fixed.I(5) + 32, Y: fixed.I(24)}} {
+			got := image.NewRGBA(image.Rect(0, 0, 200, 40))
+			want := image.NewRGBA(got.Rect)
+			end := atlas.drawString(got, dot, "Hello, Wörld! ffi", face, c)
+
+			d := font.Drawer{Dst: want, Src: image.NewUniform(c), Face: face, Dot: dot}
+			d.DrawString("Hello, Wörld! ffi")
+
+			if maxDiff(got, image.NewRGBA(got.Rect)) == 0 {
+				t.Fatalf("the atlas drew nothing")
+			}
+			if end != d.Dot {
+				t.Errorf("the dot ends at %v, the drawer at %v", end, d.Dot)
+			}
+			if diff := maxDiff(got, want); diff > 1 {
+				t.Errorf("at %v the atlas differs from the drawer by %v", dot, diff)
+			}
+		}
+	}
+}
+
+// text on the gpu goes over Img blended over the clear color,
+// text on the cpu into Img: the same, also where Img is translucent
+func TestAtlasBlendsLikeTheGPU(t *testing.T) {
+	face := Font("sans", 16)
+	text := color.RGBA{0, 120, 0, 200} // premultiplied
+	clearColor := image.NewUniform(defaultClearColor)
+	bounds := image.Rect(0, 0, 120, 30)
+
+	img := image.NewRGBA(bounds)
+	// transparent on the left, translucent blue on the right
+	draw.Draw(img, image.Rect(60, 0, 120, 30), image.NewUniform(color.RGBA{0, 0, 100, 128}), image.ZP, draw.Src)
+
+	// cpu: into Img, then Img over the clear color
+	cpuImg := image.NewRGBA(bounds)
+	draw.Draw(cpuImg, bounds, img, image.ZP, draw.Src)
+	atlas.drawString(cpuImg, fixed.P(4, 20), "text text text", face, text)
+	cpu := image.NewRGBA(bounds)
+	draw.Draw(cpu, bounds, clearColor, image.ZP, draw.Src)
+	draw.Draw(cpu, bounds, cpuImg, image.ZP, draw.Over)
+
+	// gpu: Img over the clear color, then the text over that
+	gpu := image.NewRGBA(bounds)
+	draw.Draw(gpu, bounds, clearColor, image.ZP, draw.Src)
+	draw.Draw(gpu, bounds, img, image.ZP, draw.Over)
+	atlas.drawString(gpu, fixed.P(4, 20), "text text text", face, text)
+
+	if diff := maxDiff(cpu, gpu); diff > 2 {
+		t.Errorf("the text drawn into Img differs from the text drawn over it by %v", diff)
+	}
+}
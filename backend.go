@@ -0,0 +1,130 @@
+/*
+   The Backend is what actually puts the composed GuiImg somewhere.
+
+   By default tomato draws with OpenGL into a glfw window. For tests or
+   headless machines the Software backend can be used instead, it needs
+   no GPU and no display:
+
+       sw := &tomato.Software{}
+       tomato.UseBackend(sw)
+       tomato.Create(800, 600, "")
+       ...
+       tomato.Draw()
+       img := sw.Frame()
+
+   Clear() only tells the backend that a new frame starts. What it presented
+   stays until the next Present(), so Frame() can be looked at after Clear()
+   and a frame can be presented in parts (see dirty.go).
+
+   The gl backend needs cgo and the X11 headers to build even if it isn't
+   used. With the headless build tag it is left out and the Software one is
+   the default, without cgo:
+
+       go test -tags headless ./...
+*/
+
+package tomato
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+type Backend interface {
+	// creates the window (or whatever) for w and must allocate w.Img
+	Create(w *Window, opts Options) error
+	// false if the backend wants to quit
+	Alive() bool
+	Destroy()
+	// called at the start of a new frame, before anything is composed. The
+	// presented frame has to stay like it is, Draw may only update parts of it.
+	Clear()
+	// shows the composed frame
+	Present(frame *image.RGBA)
+}
+
+// Backends with a clipboard implement it too, see ClipboardText()
+type Clipboard interface {
+	ClipboardText() string
+	SetClipboardText(text string)
+}
+
+// for backends without a clipboard, it only lives as long as the program
+var localClipboard string
+
+// The text in the clipboard of the current window's backend
+func ClipboardText() string {
+	if c, ok := current.backend.(Clipboard); ok {
+		return c.ClipboardText()
+	}
+	return localClipboard
+}
+
+func SetClipboardText(text string) {
+	if c, ok := current.backend.(Clipboard); ok {
+		c.SetClipboardText(text)
+		return
+	}
+	localClipboard = text
+}
+
+// the color behind the composed image, where nothing was drawn, see Options.ClearColor
+var defaultClearColor = color.RGBA{230, 217, 77, 255}
+
+// Software is a pure Go Backend. It blends the composed image over the clear color,
+// the same way the gl backend does, and keeps the result in memory.
+type Software struct {
+	w      *Window
+	frame  *image.RGBA
+	closed bool
+}
+
+// Only the size and the clear color of opts are used.
+func (s *Software) Create(w *Window, opts Options) error {
+	bounds := image.Rect(0, 0, opts.Width, opts.Height)
+	s.w = w
+	w.Img = image.NewRGBA(bounds)
+	s.frame = image.NewRGBA(bounds)
+	s.closed = false
+	draw.Draw(s.frame, bounds, image.NewUniform(w.clearColor), image.ZP, draw.Src)
+	return nil
+}
+
+func (s *Software) Alive() bool {
+	return !s.closed
+}
+
+func (s *Software) Destroy() {
+	s.closed = true
+}
+
+// Nothing to do, Present() starts from the clear color where it draws anyway.
+// Like that Frame() stays valid until the next Present(), and the parts that
+// didn't change since are still there for presentDirty.
+func (s *Software) Clear() {}
+
+func (s *Software) Present(frame *image.RGBA) {
+	s.presentDirty(frame, []image.Rectangle{frame.Bounds()})
+}
+
+// only the dirty rectangles are blended again, Draw gives the whole frame after a resize
+func (s *Software) presentDirty(frame *image.RGBA, dirty []image.Rectangle) {
+	if !frame.Bounds().Eq(s.frame.Bounds()) {
+		s.frame = image.NewRGBA(frame.Bounds())
+	}
+	for _, r := range dirty {
+		draw.Draw(s.frame, r, image.NewUniform(s.w.clearColor), image.ZP, draw.Src)
+		draw.Draw(s.frame, r, frame, r.Min, draw.Over)
+	}
+}
+
+// Simulates a resize of the window, like the user dragging its border.
+func (s *Software) Resize(width, height int) {
+	s.w.resized(width, height)
+}
+
+// The last presented frame. It is reused, so copy it if you want to keep it.
+func (s *Software) Frame() *image.RGBA {
+	return s.frame
+}
@@ -0,0 +1,38 @@
+package tomato
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// the Software backend shows the composed image over the clear color and
+// keeps it until the next Present, Clear doesn't take it away
+func TestSoftwareFrame(t *testing.T) {
+	sw := &Software{}
+	clearColor := color.RGBA{10, 20, 30, 255}
+	w, err := NewWindowWithBackend(sw, Options{Width: 20, Height: 10, ClearColor: clearColor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Destroy()
+	if got := sw.Frame().RGBAAt(5, 5); got != clearColor {
+		t.Errorf("a new window shows %v, want the clear color %v", got, clearColor)
+	}
+
+	red := color.RGBA{255, 0, 0, 255}
+	w.Clear()
+	w.ToDraw(image.Rect(0, 0, 10, 10), image.NewUniform(red))
+	w.Draw()
+	if got := sw.Frame().RGBAAt(5, 5); got != red {
+		t.Errorf("the drawn part shows %v, want %v", got, red)
+	}
+	if got := sw.Frame().RGBAAt(15, 5); got != clearColor {
+		t.Errorf("the part without anything shows %v, want the clear color %v", got, clearColor)
+	}
+
+	w.Clear()
+	if got := sw.Frame().RGBAAt(5, 5); got != red {
+		t.Errorf("after Clear the frame shows %v, want the last one %v", got, red)
+	}
+}
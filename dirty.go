@@ -0,0 +1,185 @@
+/*
+   Dirty rectangles: Draw only composes and uploads the parts of the frame
+   that changed. The draw queue of the last frame is kept and compared with
+   the new one, the places of the ops that aren't in both (in the same order)
+   are dirty. Everything else of Img, and of the texture on the GPU, stays
+   like it was.
+
+   Ops are the same if they are drawn at the same place the same way, with
+   an image.Uniform of the same color, an *image.RGBA with the same pixels
+   or else the very same image. Call Invalidate after drawing into Img
+   directly, or into an image that isn't an *image.RGBA, that was queued
+   before:
+
+       tomato.ToDraw(where, canvas) // a custom image.Image
+       ... canvas changes
+       tomato.Invalidate(where)
+
+   Without Clear() the frame is drawn over the last one like before, then
+   the ops are what is uploaded.
+
+   OnDraw reports what every Draw did, to see what it costs:
+
+       tomato.OnDraw(func(s tomato.DrawStats) {
+           fmt.Println(len(s.Dirty), "rectangles,", s.Uploaded, "bytes")
+       })
+*/
+
+package tomato
+
+import (
+	"hash/maphash"
+	"image"
+	"image/color"
+	"reflect"
+	"slices"
+)
+
+const MAX_DIRTY_RECTS int = 32 // more become one rectangle around them
+
+// What a Draw did, see OnDraw
+type DrawStats struct {
+	Ops      int               // in the draw queue
+	Dirty    []image.Rectangle // composed again and uploaded, they don't overlap
+	Uploaded int               // bytes handed to the backend
+}
+
+// Backends that can update parts of the presented frame, the gl and the Software one
+type dirtyBackend interface {
+	// like Present, only the pixels in dirty changed since the last call
+	presentDirty(frame *image.RGBA, dirty []image.Rectangle)
+}
+
+// f is called after every Draw of the current window, nil stops it
+func OnDraw(f func(DrawStats)) {
+	current.OnDraw(f)
+}
+
+func (w *Window) OnDraw(f func(DrawStats)) {
+	w.drawLock.Lock()
+	w.onDraw = f
+	w.drawLock.Unlock()
+}
+
+// r is composed again and uploaded with the next Draw, see the dirty rectangles
+func Invalidate(r image.Rectangle) {
+	current.Invalidate(r)
+}
+
+func (w *Window) Invalidate(r image.Rectangle) {
+	w.drawLock.Lock()
+	w.invalid = append(w.invalid, r)
+	w.drawLock.Unlock()
+}
+
+var opSeed = maphash.MakeSeed()
+
+// the hash of the pixels of an *image.RGBA op, 0 for the other images
+// @Speed reads all of them every frame, still a lot less than composing and uploading them
+func opHash(op drawOp) uint64 {
+	img, ok := op.img.(*image.RGBA)
+	if !ok {
+		return 0
+	}
+	r := op.where.Sub(op.where.Min).Add(op.src).Intersect(img.Rect)
+	var h maphash.Hash
+	h.SetSeed(opSeed)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i := img.PixOffset(r.Min.X, y)
+		h.Write(img.Pix[i : i+4*r.Dx()])
+	}
+	return h.Sum64()
+}
+
+func sameOp(a, b drawOp) bool {
+	if a.where != b.where || a.src != b.src || a.layer != b.layer || a.op != b.op {
+		return false
+	}
+	if a.text != nil || b.text != nil {
+		return a.text != nil && b.text != nil && a.text.color == b.text.color && slices.Equal(a.text.glyphs, b.text.glyphs)
+	}
+	if ua, ok := a.img.(*image.Uniform); ok {
+		ub, ok := b.img.(*image.Uniform)
+		return ok && sameColor(ua.C, ub.C)
+	}
+	if _, ok := a.img.(*image.RGBA); ok {
+		_, ok := b.img.(*image.RGBA)
+		return ok && a.hash == b.hash
+	}
+	// the same image, == panics for the types that can't be compared
+	ta, tb := reflect.TypeOf(a.img), reflect.TypeOf(b.img)
+	return ta == tb && ta != nil && ta.Comparable() && a.img == b.img
+}
+
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
+
+// The places where the composition of the ops in next differs from the one
+// of last. The ops that aren't in both, in the same order, are there.
+// Text on the gpu isn't in Img, it only counts when it moves to the CPU or back.
+// @Speed goes through the rest of last for every op that isn't in it
+func diffQueues(last, next []drawOp) []image.Rectangle {
+	var dirty []image.Rectangle
+	changed := func(op drawOp) {
+		if !op.gpu {
+			dirty = append(dirty, op.where)
+		}
+	}
+	j := 0
+	for _, op := range next {
+		k := j
+		for k < len(last) && !sameOp(last[k], op) {
+			k++
+		}
+		if k == len(last) {
+			changed(op)
+			continue
+		}
+		for _, gone := range last[j:k] {
+			changed(gone)
+		}
+		if last[k].gpu != op.gpu {
+			dirty = append(dirty, op.where)
+		}
+		j = k + 1
+	}
+	for _, gone := range last[j:] {
+		changed(gone)
+	}
+	return dirty
+}
+
+// The rectangles inside bounds, the ones that overlap are merged until none do.
+// More than MAX_DIRTY_RECTS become one.
+func mergeRects(rects []image.Rectangle, bounds image.Rectangle) []image.Rectangle {
+	var merged []image.Rectangle
+	for _, r := range rects {
+		r = r.Intersect(bounds)
+		if r.Empty() {
+			continue
+		}
+		for i := 0; i < len(merged); {
+			if merged[i].Overlaps(r) {
+				// the union may overlap the ones before, from the start again
+				r = r.Union(merged[i])
+				merged[i] = merged[len(merged)-1]
+				merged = merged[:len(merged)-1]
+				i = 0
+				continue
+			}
+			i++
+		}
+		merged = append(merged, r)
+	}
+	if len(merged) > MAX_DIRTY_RECTS {
+		all := merged[0]
+		for _, r := range merged[1:] {
+			all = all.Union(r)
+		}
+		merged = []image.Rectangle{all}
+	}
+	return merged
+}
@@ -0,0 +1,135 @@
+package tomato
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"testing"
+)
+
+func TestDiffQueues(t *testing.T) {
+	red := image.NewUniform(color.RGBA{255, 0, 0, 255})
+	blue := image.NewUniform(color.RGBA{0, 0, 255, 255})
+	pixels := func(c uint8) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+		img.Pix[0] = c
+		return img
+	}
+	op := func(x int, img image.Image) drawOp {
+		o := drawOp{where: image.Rect(x, 0, x+10, 10), img: img, op: draw.Over}
+		o.hash = opHash(o)
+		return o
+	}
+	at := func(xs ...int) []image.Rectangle {
+		var rects []image.Rectangle
+		for _, x := range xs {
+			rects = append(rects, image.Rect(x, 0, x+10, 10))
+		}
+		return rects
+	}
+	gpu := op(0, red)
+	gpu.gpu = true
+
+	tests := []struct {
+		name       string
+		last, next []drawOp
+		want       []image.Rectangle
+	}{
+		{"nothing", nil, nil, nil},
+		{"same", []drawOp{op(0, red), op(10, blue)}, []drawOp{op(0, red), op(10, blue)}, nil},
+		{"same color, other uniform", []drawOp{op(0, red)}, []drawOp{op(0, image.NewUniform(color.RGBA{255, 0, 0, 255}))}, nil},
+		{"new", nil, []drawOp{op(0, red)}, at(0)},
+		{"gone", []drawOp{op(0, red)}, nil, at(0)},
+		{"other color", []drawOp{op(0, red)}, []drawOp{op(0, blue)}, at(0, 0)},
+		{"moved", []drawOp{op(0, red)}, []drawOp{op(20, red)}, at(20, 0)},
+		{"inserted", []drawOp{op(0, red), op(20, red)}, []drawOp{op(0, red), op(10, blue), op(20, red)}, at(10)},
+		{"removed in between", []drawOp{op(0, red), op(10, blue), op(20, red)}, []drawOp{op(0, red), op(20, red)}, at(10)},
+		{"same pixels", []drawOp{op(0, pixels(1))}, []drawOp{op(0, pixels(1))}, nil},
+		{"other pixels", []drawOp{op(0, pixels(1))}, []drawOp{op(0, pixels(2))}, at(0, 0)},
+		{"text on the gpu", nil, []drawOp{gpu}, nil},
+		{"text to the gpu", []drawOp{op(0, red)}, []drawOp{gpu}, at(0)},
+	}
+	for _, tt := range tests {
+		if got := diffQueues(tt.last, tt.next); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: diffQueues gave %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeRects(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 100)
+	many := make([]image.Rectangle, MAX_DIRTY_RECTS+1)
+	for i := range many {
+		many[i] = image.Rect(i*3, 0, i*3+1, 1)
+	}
+
+	tests := []struct {
+		name  string
+		rects []image.Rectangle
+		want  []image.Rectangle
+	}{
+		{"none", nil, nil},
+		{"one", []image.Rectangle{image.Rect(10, 10, 20, 20)}, []image.Rectangle{image.Rect(10, 10, 20, 20)}},
+		{"outside", []image.Rectangle{image.Rect(200, 0, 210, 10), image.Rect(10, 10, 10, 20)}, nil},
+		{"clipped", []image.Rectangle{image.Rect(-10, 90, 10, 110)}, []image.Rectangle{image.Rect(0, 90, 10, 100)}},
+		{"apart", []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(10, 0, 20, 10)},
+			[]image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(10, 0, 20, 10)}},
+		{"overlap", []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(5, 5, 15, 15)}, []image.Rectangle{image.Rect(0, 0, 15, 15)}},
+		{"twice", []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(0, 0, 10, 10)}, []image.Rectangle{image.Rect(0, 0, 10, 10)}},
+		// the union of the last two overlaps the first one
+		{"chain", []image.Rectangle{image.Rect(0, 20, 10, 30), image.Rect(20, 0, 30, 10), image.Rect(5, 5, 25, 25)},
+			[]image.Rectangle{image.Rect(0, 0, 30, 30)}},
+		{"too many", many, []image.Rectangle{image.Rect(0, 0, MAX_DIRTY_RECTS*3+1, 1)}},
+	}
+	for _, tt := range tests {
+		got := mergeRects(tt.rects, bounds)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: mergeRects gave %v, want %v", tt.name, got, tt.want)
+		}
+		for i, a := range got {
+			for _, b := range got[i+1:] {
+				if a.Overlaps(b) {
+					t.Errorf("%v: %v and %v overlap", tt.name, a, b)
+				}
+			}
+		}
+	}
+}
+
+// a frame drawn over the last one looks like the same frame drawn from scratch
+func TestDrawDirtyLikeFull(t *testing.T) {
+	red := image.NewUniform(color.RGBA{255, 0, 0, 255})
+	blue := image.NewUniform(color.RGBA{0, 0, 255, 128})
+	frames := []func(w *Window){
+		func(w *Window) {
+			w.ToDraw(image.Rect(0, 0, 40, 40), red)
+			w.ToDraw(image.Rect(20, 20, 60, 60), blue)
+		},
+		func(w *Window) {
+			w.ToDraw(image.Rect(0, 0, 40, 40), red)
+			w.ToDraw(image.Rect(30, 10, 70, 50), blue)
+		},
+	}
+	render := func(frames ...func(*Window)) *image.RGBA {
+		sw := &Software{}
+		w, err := NewWindowWithBackend(sw, Options{Width: 80, Height: 80})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stats DrawStats
+		w.OnDraw(func(s DrawStats) { stats = s })
+		for _, f := range frames {
+			w.Clear()
+			f(w)
+			w.Draw()
+		}
+		if len(frames) > 1 && stats.Uploaded >= 80*80*4 {
+			t.Errorf("the second frame uploaded all %v bytes", stats.Uploaded)
+		}
+		return sw.Frame()
+	}
+	if got, want := render(frames...), render(frames[1]); !reflect.DeepEqual(got.Pix, want.Pix) {
+		t.Errorf("the frame drawn over the last one differs from the one drawn from scratch")
+	}
+}
@@ -17,16 +17,22 @@ func _() {
 	_ = x[KeyUp-7]
 	_ = x[KeyRepeat-8]
 	_ = x[RuneTyped-9]
+	_ = x[WinResize-10]
+	_ = x[PadConnect-11]
+	_ = x[PadDisconnect-12]
+	_ = x[PadDown-13]
+	_ = x[PadUp-14]
+	_ = x[PadMove-15]
 }
 
-const _EvKind_name = "WinCloseMouMoveMouDownMouUpMouScrollKeyDownKeyUpKeyRepeatRuneTyped"
+const _EvKind_name = "WinCloseMouMoveMouDownMouUpMouScrollKeyDownKeyUpKeyRepeatRuneTypedWinResizePadConnectPadDisconnectPadDownPadUpPadMove"
 
-var _EvKind_index = [...]uint8{0, 8, 15, 22, 27, 36, 43, 48, 57, 66}
+var _EvKind_index = [...]uint8{0, 8, 15, 22, 27, 36, 43, 48, 57, 66, 75, 85, 98, 105, 110, 117}
 
 func (i EvKind) String() string {
-	i -= 1
-	if i >= EvKind(len(_EvKind_index)-1) {
-		return "EvKind(" + strconv.FormatInt(int64(i+1), 10) + ")"
+	idx := int(i) - 1
+	if i < 1 || idx >= len(_EvKind_index)-1 {
+		return "EvKind(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _EvKind_name[_EvKind_index[i]:_EvKind_index[i+1]]
+	return _EvKind_name[_EvKind_index[idx]:_EvKind_index[idx+1]]
 }
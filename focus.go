@@ -0,0 +1,127 @@
+/*
+   Keyboard focus.
+
+   Every widget that can have the focus registers itself with focusable()
+   when it is made, Tab and Shift+Tab move the focus along that order
+   (wrapping around). A click focuses the widget under the mouse and a click
+   somewhere else takes the focus away again.
+
+   The focused button is clicked with Enter or Space, the focused text field
+   takes the typed text. After Tab was used the focused widget gets a ring in
+   the Focus color of its theme, so you see where you are.
+*/
+
+package tomato
+
+import (
+	"image"
+)
+
+// The widget that has the focus, 0 if none
+func Focused() ID {
+	return ui_frame.focus
+}
+
+// Gives the focus to the widget id, see GetID. 0 takes it away.
+func SetFocus(id ID) {
+	ui_frame.focus = id
+	ui_frame.focusVisible = id != 0
+}
+
+// Registers a widget that can take the focus, in the order of Tab.
+// Returns true if it has the focus.
+func (frame *Ui_Frame) focusable(id ID, target image.Rectangle) bool {
+	frame.order = append(frame.order, id)
+
+	if current.MouseDownL && !frame.previousDown {
+		if mouseIn(target) {
+			frame.focus = id
+			frame.focusVisible = false
+		} else if frame.focus == id {
+			frame.focus = 0
+		}
+	}
+	return frame.focus == id
+}
+
+// Removes the first KeyDown of one of keys from the keyboard events, true if there was one.
+// For the focused widget only.
+func (frame *Ui_Frame) takeKey(keys ...Key) bool {
+	return frame.takeKeyEv(false, keys)
+}
+
+// like takeKey, but a KeyRepeat counts too
+func (frame *Ui_Frame) takeKeyRepeating(keys ...Key) bool {
+	return frame.takeKeyEv(true, keys)
+}
+
+func (frame *Ui_Frame) takeKeyEv(repeat bool, keys []Key) bool {
+	frame.keyboardLock.Lock()
+	defer frame.keyboardLock.Unlock()
+	for i, ev := range frame.keyboard {
+		if ev.Kind != KeyDown && !(repeat && ev.Kind == KeyRepeat) {
+			continue
+		}
+		for _, k := range keys {
+			if ev.Key == k {
+				frame.keyboard = append(frame.keyboard[:i], frame.keyboard[i+1:]...)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// moves the focus by the Tabs of this frame, called by DrawUi
+func (frame *Ui_Frame) endFocus() {
+	frame.keyboardLock.Lock()
+	tabs := frame.tabs
+	frame.tabs = 0
+	frame.keyboardLock.Unlock()
+
+	i := -1
+	for j, id := range frame.order {
+		if id == frame.focus {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		// it's gone
+		frame.focus = 0
+	}
+
+	if tabs != 0 && len(frame.order) > 0 {
+		n := len(frame.order)
+		if i < 0 {
+			// the first Tab goes to the first widget, Shift+Tab to the last
+			if tabs > 0 {
+				i, tabs = 0, tabs-1
+			} else {
+				i, tabs = n-1, tabs+1
+			}
+		}
+		i = ((i+tabs)%n + n) % n
+		frame.focus = frame.order[i]
+		frame.focusVisible = true
+	}
+
+	frame.order = frame.order[:0]
+}
+
+// for the focused widget, if the focus came from the keyboard
+func drawFocusRing(target image.Rectangle, theme *ButtonColorTheme) {
+	if !ui_frame.focusVisible {
+		return
+	}
+	c := theme.Focus
+	if c.A == 0 {
+		c = ui_frame.DefaultTheme.Focus
+	}
+	ring := image.NewUniform(c)
+	r, w := target, ui_frame.style().FocusWidth
+	ToDraw(image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+w), ring)
+	ToDraw(image.Rect(r.Min.X, r.Max.Y-w, r.Max.X, r.Max.Y), ring)
+	ToDraw(image.Rect(r.Min.X, r.Min.Y, r.Min.X+w, r.Max.Y), ring)
+	ToDraw(image.Rect(r.Max.X-w, r.Min.Y, r.Max.X, r.Max.Y), ring)
+}
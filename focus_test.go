@@ -0,0 +1,45 @@
+package tomato
+
+import (
+	"image"
+	"testing"
+)
+
+// two layouts that both have a TextButton(0, ...)
+func twoLayouts(ids *[2]ID) {
+	Layout(0, Vertical, image.Rect(0, 0, 100, 100))
+	ids[0] = intID(0)
+	TextButton(0, "left", nil)
+	Layout(1, Vertical, image.Rect(100, 0, 200, 100))
+	ids[1] = intID(0)
+	TextButton(0, "right", nil)
+	DrawUi()
+}
+
+func TestFocusSameIntIDInTwoLayouts(t *testing.T) {
+	testWindow(t, 200, 100)
+
+	var ids [2]ID
+	twoLayouts(&ids)
+	if ids[0] == ids[1] {
+		t.Fatalf("TextButton(0, ...) has the same ID in both layouts: %v", ids[0])
+	}
+
+	for i, want := range []ID{ids[0], ids[1], ids[0]} {
+		pressKey(Tab, 0)
+		twoLayouts(&ids)
+		if Focused() != want {
+			t.Errorf("after %v Tabs the focus is %v, want %v", i+1, Focused(), want)
+		}
+	}
+
+	// a click focuses only the button under the mouse
+	moveTo(110, 10)
+	press(MouseLeft)
+	twoLayouts(&ids)
+	release(MouseLeft)
+	twoLayouts(&ids)
+	if Focused() != ids[1] {
+		t.Errorf("the click focused %v, want the right button %v", Focused(), ids[1])
+	}
+}
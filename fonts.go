@@ -0,0 +1,317 @@
+/*
+   Fonts by name, loaded from TrueType/OpenType files (.ttf, .otf, the first
+   font of .ttc/.otc):
+
+       tomato.LoadFont("inter", "fonts/Inter-Regular.ttf")
+       tomato.LoadFont("noto-cjk", "fonts/NotoSansCJK-Regular.otf")
+       tomato.FontFallback("inter", "noto-cjk") // for the glyphs inter doesn't have
+
+       heading := tomato.Font("inter", 36) // the same face every time
+
+   Built in are the Go fonts "mono" (the default of the Ui), "mono-bold",
+   "mono-italic", "mono-bold-italic", "sans", "sans-bold", "sans-italic"
+   and "sans-bold-italic". Sizes are in points at DPI dots per inch, faces
+   are cached per font, size and DPI.
+
+   Themes pick the font of the widgets with FontName and FontSize of the
+   Style, single widgets with PushStyle or the FontFace of their theme:
+
+       s := tomato.CurrentStyle()
+       s.FontName, s.FontSize = "sans-bold", 36
+       tomato.PushStyle(s)
+       tomato.TextButton(0, "Settings", nil)
+       tomato.PopStyle()
+*/
+
+package tomato
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+	"sync"
+
+	gotext "github.com/go-text/typesetting/font"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Dots per inch of the faces made by Font
+var DPI float64 = 72
+
+// parsed the first time they are used
+var builtinFonts = map[string][]byte{
+	"mono":             gomono.TTF,
+	"mono-bold":        gomonobold.TTF,
+	"mono-italic":      gomonoitalic.TTF,
+	"mono-bold-italic": gomonobolditalic.TTF,
+	"sans":             goregular.TTF,
+	"sans-bold":        gobold.TTF,
+	"sans-italic":      goitalic.TTF,
+	"sans-bold-italic": gobolditalic.TTF,
+}
+
+type loadedFont struct {
+	font      *opentype.Font
+	data      []byte
+	shaping   *gotext.Face // parsed from data the first time text is shaped with it
+	fallbacks []string
+}
+
+// the font and the size in pixels of a face made by makeFace, for shaping
+type faceSource struct {
+	font  *loadedFont
+	scale fixed.Int26_6
+}
+
+type faceKey struct {
+	name      string
+	size, dpi float64
+}
+
+// A face made by newFace, it knows where it came from. The faces of replaced
+// fonts are still used by who has them, so this isn't kept in a map next to faces.
+type fontFace struct {
+	font.Face
+	key faceKey
+	src faceSource
+}
+
+var (
+	fontLock sync.Mutex
+	fonts    = make(map[string]*loadedFont)
+	// @Memory never shrinks, every size that was asked for stays (until a font is loaded)
+	faces = make(map[faceKey]font.Face)
+	// counts the changes of fonts, the Ui makes its faces again after one
+	fontsVersion int
+)
+
+// Loads the font file at path as name, a font that is already there is replaced
+func LoadFont(name, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return LoadFontData(name, data)
+}
+
+// Like LoadFont, with the content of the file.
+// The faces Font gave before keep the old font, the theme of the Ui gets the
+// new faces with the next frame.
+func LoadFontData(name string, data []byte) error {
+	collection, err := opentype.ParseCollection(data)
+	if err != nil {
+		return fmt.Errorf("font %q: %w", name, err)
+	}
+	f, err := collection.Font(0)
+	if err != nil {
+		return fmt.Errorf("font %q: %w", name, err)
+	}
+
+	fontLock.Lock()
+	defer fontLock.Unlock()
+	fallbacks := []string(nil)
+	if old, ok := fonts[name]; ok {
+		fallbacks = old.fallbacks
+	}
+	fonts[name] = &loadedFont{font: f, data: data, fallbacks: fallbacks}
+	clear(faces)
+	fontsVersion++
+	return nil
+}
+
+// The glyphs name doesn't have are taken from the fallbacks, the first one that has them.
+// The fallbacks don't have to be loaded yet. Like with LoadFontData the faces
+// from before don't change.
+func FontFallback(name string, fallbacks ...string) {
+	fontLock.Lock()
+	defer fontLock.Unlock()
+	f, err := fontByName(name)
+	if err != nil {
+		panic("\ntomato ERROR: " + err.Error() + "\n")
+	}
+	f.fallbacks = fallbacks
+	clear(faces)
+	fontsVersion++
+}
+
+func fontsChanged(since int) (bool, int) {
+	fontLock.Lock()
+	defer fontLock.Unlock()
+	return since != fontsVersion, fontsVersion
+}
+
+// The face of the font name at size points, see LoadFont
+func Font(name string, size float64) font.Face {
+	return FontDPI(name, size, DPI)
+}
+
+func FontDPI(name string, size, dpi float64) font.Face {
+	face, err := makeFace(name, size, dpi)
+	if err != nil {
+		panic("\ntomato ERROR: " + err.Error() + "\n")
+	}
+	return face
+}
+
+func makeFace(name string, size, dpi float64) (font.Face, error) {
+	fontLock.Lock()
+	defer fontLock.Unlock()
+
+	key := faceKey{name, size, dpi}
+	if face, ok := faces[key]; ok {
+		return face, nil
+	}
+
+	f, err := fontByName(name)
+	if err != nil {
+		return nil, err
+	}
+	face, err := newFace(f, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.fallbacks) > 0 {
+		chain := &fallbackFace{faces: []font.Face{face}, key: key, which: make(map[rune]int)}
+		for _, fallback := range f.fallbacks {
+			// the ones that aren't loaded are skipped
+			fb, err := fontByName(fallback)
+			if err != nil {
+				continue
+			}
+			face, err := newFace(fb, faceKey{fallback, size, dpi})
+			if err == nil {
+				chain.faces = append(chain.faces, face)
+			}
+		}
+		faces[key] = chain
+		return chain, nil
+	}
+	faces[key] = face
+	return face, nil
+}
+
+// the name, size and DPI face was made with by Font, false for other faces
+func faceKeyOf(face font.Face) (faceKey, bool) {
+	switch f := face.(type) {
+	case *fontFace:
+		return f.key, true
+	case *fallbackFace:
+		return f.key, f.key.name != ""
+	}
+	return faceKey{}, false
+}
+
+// call it with fontLock
+func newFace(f *loadedFont, key faceKey) (*fontFace, error) {
+	face, err := opentype.NewFace(f.font, &opentype.FaceOptions{Size: key.size, DPI: key.dpi, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	return &fontFace{
+		Face: face,
+		key:  key,
+		// rounded like opentype.NewFace does
+		src: faceSource{font: f, scale: fixed.Int26_6(0.5 + key.size*key.dpi*64/72)},
+	}, nil
+}
+
+// the source of face, false if makeFace didn't make it. Parses the font for shaping.
+func faceSourceOf(face font.Face) (faceSource, bool) {
+	f, ok := face.(*fontFace)
+	if !ok {
+		return faceSource{}, false
+	}
+	fontLock.Lock()
+	defer fontLock.Unlock()
+	src := f.src
+	if src.font.shaping == nil {
+		parsed, err := gotext.ParseTTC(bytes.NewReader(src.font.data))
+		if err != nil || len(parsed) == 0 {
+			return src, false
+		}
+		src.font.shaping = parsed[0]
+	}
+	return src, true
+}
+
+// call it with fontLock
+func fontByName(name string) (*loadedFont, error) {
+	if f, ok := fonts[name]; ok {
+		return f, nil
+	}
+	data, ok := builtinFonts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown font %q, load it with LoadFont(...) first", name)
+	}
+	parsed, err := opentype.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	f := &loadedFont{font: parsed, data: data}
+	fonts[name] = f
+	return f, nil
+}
+
+// Takes every glyph from the first face that has it, the metrics are the ones of the first face
+type fallbackFace struct {
+	faces []font.Face
+	key   faceKey
+	lock  sync.Mutex   // of which, the text is measured, shaped and drawn on many goroutines
+	which map[rune]int // index into faces
+}
+
+func (f *fallbackFace) pick(r rune) font.Face {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	i, ok := f.which[r]
+	if !ok {
+		for j, face := range f.faces {
+			if _, has := face.GlyphAdvance(r); has {
+				i = j
+				break
+			}
+		}
+		f.which[r] = i
+	}
+	return f.faces[i]
+}
+
+func (f *fallbackFace) Close() error {
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.pick(r).Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.pick(r).GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.pick(r).GlyphAdvance(r)
+}
+
+// only between glyphs of the same face
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := f.pick(r0)
+	if face != f.pick(r1) {
+		return 0
+	}
+	return face.Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
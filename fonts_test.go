@@ -0,0 +1,75 @@
+package tomato
+
+import (
+	"sync"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// the fallbacks are picked from many goroutines at once
+func TestFallbackConcurrent(t *testing.T) {
+	if err := LoadFontData("test-fallback", goregular.TTF); err != nil {
+		t.Fatal(err)
+	}
+	FontFallback("test-fallback", "mono")
+	face := Font("test-fallback", 12)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for r := rune(32 + g); r < 0x3000; r += 8 {
+				face.GlyphAdvance(r)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// the theme gets the faces of a font loaded again with the next frame
+func TestLoadFontSyncsTheme(t *testing.T) {
+	testWindow(t, 100, 100)
+
+	if err := LoadFontData("test-theme", goregular.TTF); err != nil {
+		t.Fatal(err)
+	}
+	s := CurrentStyle()
+	s.FontName = "test-theme"
+	SetStyle(s)
+	old := ui_frame.DefaultTheme.FontFace
+
+	if err := LoadFontData("test-theme", gobold.TTF); err != nil {
+		t.Fatal(err)
+	}
+	DrawUi()
+	face := ui_frame.DefaultTheme.FontFace
+	if face == old || face != Font("test-theme", TEXT_SIZE) {
+		t.Errorf("the theme still has the face of the old font")
+	}
+	if ui_frame.defaultFont != Font("mono", TEXT_SIZE) {
+		t.Errorf("the default font is from before LoadFontData")
+	}
+}
+
+// a face of a font loaded again still knows its font, without a map that keeps it
+func TestReplacedFontFace(t *testing.T) {
+	if err := LoadFontData("test-replaced", goregular.TTF); err != nil {
+		t.Fatal(err)
+	}
+	old := Font("test-replaced", 20)
+	if err := LoadFontData("test-replaced", gobold.TTF); err != nil {
+		t.Fatal(err)
+	}
+	if Font("test-replaced", 20) == old {
+		t.Fatalf("the face didn't change with the font")
+	}
+	if _, ok := faceSourceOf(old); !ok {
+		t.Errorf("the old face lost its font")
+	}
+	if key, ok := faceKeyOf(old); !ok || key != (faceKey{"test-replaced", 20, DPI}) {
+		t.Errorf("the old face is %v, want test-replaced 20", key)
+	}
+}
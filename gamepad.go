@@ -0,0 +1,92 @@
+/*
+   Gamepads, using the gamepad mappings of glfw (so an Xbox layout for all pads).
+   Joysticks without a mapping are ignored.
+
+   glfw has no callbacks for the buttons, so the gl backend polls the pads
+   every frame in Alive() and sends the changes as events:
+       PadConnect, PadDisconnect  Ev.Pad
+       PadDown, PadUp             Ev.Pad, Ev.PadButton
+       PadMove                    Ev.Pad, Ev.Axis, Ev.Value
+   The state can also be polled, like MouseX/MouseDownL, with Pad(i).
+*/
+
+package tomato
+
+const MAX_PADS int = 16
+
+//go:generate stringer -type=PadButton
+type PadButton uint8
+
+// in the order of glfw
+const (
+	PadA PadButton = iota
+	PadB
+	PadX
+	PadY
+	PadLeftBumper
+	PadRightBumper
+	PadBack
+	PadStart
+	PadGuide
+	PadLeftThumb
+	PadRightThumb
+	PadDpadUp
+	PadDpadRight
+	PadDpadDown
+	PadDpadLeft
+	padButtonCount
+)
+
+//go:generate stringer -type=PadAxis
+type PadAxis uint8
+
+// in the order of glfw
+const (
+	AxisLeftX PadAxis = iota
+	AxisLeftY
+	AxisRightX
+	AxisRightY
+	AxisLeftTrigger  // -1 released, 1 fully pressed
+	AxisRightTrigger // -1 released, 1 fully pressed
+	padAxisCount
+)
+
+type PadState struct {
+	Connected bool
+	Buttons   [padButtonCount]bool
+	Axes      [padAxisCount]float32 // from -1 to 1
+}
+
+// smaller changes of an axis don't send a PadMove, sticks are jittery
+const padAxisEpsilon = 0.01
+
+// State of gamepad i of the current window, from 0 to MAX_PADS-1.
+// The other ones are never connected.
+func Pad(i int) PadState {
+	if i < 0 || i >= MAX_PADS {
+		return PadState{}
+	}
+	return current.Pads[i]
+}
+
+// called by Inject, keeps Window.Pads in sync with the events
+func (w *Window) updatePad(ev Ev) {
+	if ev.Pad < 0 || ev.Pad >= MAX_PADS {
+		return
+	}
+	pad := &w.Pads[ev.Pad]
+	switch ev.Kind {
+	case PadConnect:
+		*pad = PadState{Connected: true}
+	case PadDisconnect:
+		*pad = PadState{}
+	case PadDown, PadUp:
+		if ev.PadButton < padButtonCount {
+			pad.Buttons[ev.PadButton] = ev.Kind == PadDown
+		}
+	case PadMove:
+		if ev.Axis < padAxisCount {
+			pad.Axes[ev.Axis] = ev.Value
+		}
+	}
+}
@@ -0,0 +1,65 @@
+//go:build !headless
+
+package tomato
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// compares the gamepads to w.Pads and sends the differences
+func (b *glBackend) pollPads() {
+	w := b.w
+	for i := range MAX_PADS {
+		joy := glfw.Joystick1 + glfw.Joystick(i)
+		connected := joy.Present() && joy.IsGamepad()
+
+		if connected != w.Pads[i].Connected {
+			kind := PadDisconnect
+			if connected {
+				kind = PadConnect
+			}
+			w.Inject(Ev{
+				Kind: kind,
+				Pad:  i,
+			})
+		}
+		if !connected {
+			continue
+		}
+
+		state := joy.GetGamepadState()
+		if state == nil {
+			continue
+		}
+
+		for button := range padButtonCount {
+			down := state.Buttons[button] == glfw.Press
+			if down == w.Pads[i].Buttons[button] {
+				continue
+			}
+			kind := PadUp
+			if down {
+				kind = PadDown
+			}
+			w.Inject(Ev{
+				Kind:      kind,
+				Pad:       i,
+				PadButton: button,
+			})
+		}
+
+		for axis := range padAxisCount {
+			value := state.Axes[axis]
+			diff := value - w.Pads[i].Axes[axis]
+			if diff < padAxisEpsilon && diff > -padAxisEpsilon {
+				continue
+			}
+			w.Inject(Ev{
+				Kind:  PadMove,
+				Pad:   i,
+				Axis:  axis,
+				Value: value,
+			})
+		}
+	}
+}
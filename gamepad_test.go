@@ -0,0 +1,29 @@
+package tomato
+
+import "testing"
+
+func TestPad(t *testing.T) {
+	testWindow(t, 100, 100)
+
+	Inject(Ev{Kind: PadConnect, Pad: 1})
+	Inject(Ev{Kind: PadDown, Pad: 1, PadButton: PadA})
+	Inject(Ev{Kind: PadConnect, Pad: MAX_PADS})
+
+	tests := []struct {
+		i         int
+		connected bool
+		a         bool
+	}{
+		{-1, false, false},
+		{0, false, false},
+		{1, true, true},
+		{MAX_PADS, false, false},
+		{1 << 20, false, false},
+	}
+	for _, tt := range tests {
+		pad := Pad(tt.i)
+		if pad.Connected != tt.connected || pad.Buttons[PadA] != tt.a {
+			t.Errorf("Pad(%v) = %+v, want connected %v and A %v", tt.i, pad, tt.connected, tt.a)
+		}
+	}
+}
@@ -0,0 +1,687 @@
+//go:build !headless
+
+/*
+   The gl backend, the default one: OpenGL in a glfw window. It needs cgo
+   and the headers of X11 (or the ones of the platform). Build with
+   -tags headless to leave it out, then only the Software backend is there
+   and everything is pure Go:
+
+       go test -tags headless ./...
+*/
+
+package tomato
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"runtime"
+	"strings"
+
+	"github.com/go-gl/gl/v4.2-core/gl" // I hope it is supported on most systems
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Lock the thread needed for glfw (and gl?)
+func init() {
+	runtime.LockOSThread()
+}
+
+type glfwWindow = glfw.Window
+
+func newDefaultBackend() Backend {
+	return &glBackend{}
+}
+
+func (w *Window) makeContextCurrent() {
+	if w.Win != nil {
+		w.Win.MakeContextCurrent()
+	}
+}
+
+func (w *Window) syncGLGlobals() {
+	if b, ok := w.backend.(*glBackend); ok {
+		GuiShader, GuiTexture, GuiQuadVAO = b.shader, b.texture, b.quadVAO
+	}
+}
+
+// Shows what was drawn, only for the gl backend
+func (w *Window) SwapBuffers() {
+	if w.Win != nil {
+		w.Win.SwapBuffers()
+	}
+}
+
+// @Todo rename Gui, because its not only for Gui stuff, its used for any 2d rendering... it's more like an overlay over existing gl stuff, so maybe hud?
+// gl stuff
+var GuiShader uint32
+var GuiTexture uint32
+var GuiQuadVAO uint32
+var GuiQuad = []float32{
+	//  X, Y, Z, U, V
+	-1.0, 1.0, 1.0, 0.0, 0.0,
+	1.0, -1.0, 1.0, 1.0, 1.0,
+	-1.0, -1.0, 1.0, 0.0, 1.0,
+	-1.0, 1.0, 1.0, 0.0, 0.0,
+	1.0, 1.0, 1.0, 1.0, 0.0,
+	1.0, -1.0, 1.0, 1.0, 1.0,
+}
+
+// the default Backend, draws with OpenGL into a glfw window
+// every window has its own gl context
+type glBackend struct {
+	w   *Window
+	win *glfw.Window
+
+	shader  uint32
+	texture uint32
+	quadVAO uint32
+
+	// the glyph atlas, see atlas.go
+	glyphShader  uint32
+	glyphVAO     uint32
+	glyphVBO     uint32
+	glyphData    []float32 // the instances of this frame
+	atlasTexture uint32
+	atlasVersion int // of the atlas in atlasTexture
+}
+
+var glWindows int // alive glfw windows, glfw is terminated when the last one is destroyed
+
+func (b *glBackend) Create(window *Window, opts Options) error {
+	if err := glfw.Init(); err != nil {
+		return err
+	}
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 2)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	glfw.WindowHint(glfw.Decorated, glfwBool(!opts.Borderless))
+	glfw.WindowHint(glfw.Resizable, glfwBool(!opts.Fixed))
+	glfw.WindowHint(glfw.TransparentFramebuffer, glfwBool(opts.Transparent))
+	glfw.WindowHint(glfw.Samples, opts.Samples)
+	// show it only after it is moved to its position
+	glfw.WindowHint(glfw.Visible, glfw.False)
+
+	var monitor *glfw.Monitor
+	if opts.Fullscreen {
+		monitors := glfw.GetMonitors()
+		if opts.Monitor < 0 || opts.Monitor >= len(monitors) {
+			return fmt.Errorf("there is no monitor %v, only %v connected", opts.Monitor, len(monitors))
+		}
+		monitor = monitors[opts.Monitor]
+		mode := monitor.GetVideoMode()
+		if opts.Width == 0 || opts.Height == 0 {
+			opts.Width, opts.Height = mode.Width, mode.Height
+		}
+		glfw.WindowHint(glfw.RefreshRate, mode.RefreshRate)
+	}
+
+	win, err := glfw.CreateWindow(opts.Width, opts.Height, opts.Title, monitor, nil)
+
+	if err != nil {
+		return err
+	}
+	glWindows++
+
+	b.w = window
+	b.win = win
+	window.Win = win
+	win.MakeContextCurrent()
+	interval := 1 // vsync
+	if opts.SwapInterval != nil {
+		interval = *opts.SwapInterval
+	}
+	glfw.SwapInterval(interval)
+
+	if opts.Position != image.ZP && monitor == nil {
+		win.SetPos(opts.Position.X, opts.Position.Y)
+	}
+	if opts.MinSize != image.ZP || opts.MaxSize != image.ZP {
+		win.SetSizeLimits(sizeLimit(opts.MinSize.X), sizeLimit(opts.MinSize.Y), sizeLimit(opts.MaxSize.X), sizeLimit(opts.MaxSize.Y))
+	}
+	if len(opts.Icon) > 0 {
+		win.SetIcon(opts.Icon)
+	}
+	win.Show()
+
+	if err = gl.Init(); err != nil {
+		return err
+	}
+
+	if err = b.openGLSetup(); err != nil {
+		return err
+	}
+
+	gl.ClearColor(glColor(window.clearColor))
+	gl.Clear(gl.DEPTH_BUFFER_BIT | gl.COLOR_BUFFER_BIT)
+	win.SwapBuffers()
+	gl.Clear(gl.DEPTH_BUFFER_BIT | gl.COLOR_BUFFER_BIT)
+
+	b.callbacksSetup()
+	return nil
+}
+
+// gl calls go to the current context, so switch to ours first
+func (b *glBackend) makeContextCurrent() {
+	if glfw.GetCurrentContext() != b.win {
+		b.win.MakeContextCurrent()
+	}
+}
+
+func glfwBool(b bool) int {
+	if b {
+		return glfw.True
+	}
+	return glfw.False
+}
+
+// 0 means no limit
+func sizeLimit(l int) int {
+	if l <= 0 {
+		return glfw.DontCare
+	}
+	return l
+}
+
+func (b *glBackend) Alive() bool {
+	if b.win.ShouldClose() {
+		return false
+	}
+	glfw.PollEvents()
+	b.pollPads()
+	return true
+}
+
+func (b *glBackend) Destroy() {
+	b.win.Destroy()
+	glWindows--
+	if glWindows == 0 {
+		glfw.Terminate()
+	}
+}
+
+func (b *glBackend) ClipboardText() string {
+	return b.win.GetClipboardString()
+}
+
+func (b *glBackend) SetClipboardText(text string) {
+	b.win.SetClipboardString(text)
+}
+
+// the texture keeps the last frame, Present only uploads what changed
+func (b *glBackend) Clear() {
+	b.makeContextCurrent()
+	gl.Clear(gl.DEPTH_BUFFER_BIT | gl.COLOR_BUFFER_BIT)
+}
+
+func (b *glBackend) Present(frame *image.RGBA) {
+	b.presentDirty(frame, []image.Rectangle{frame.Bounds()})
+}
+
+// If the Gui is on the texture on the GPU, the additional draw calls don't matter anyway
+// we could just Draw it using Alpha blending
+func (b *glBackend) presentDirty(frame *image.RGBA, dirty []image.Rectangle) {
+	b.makeContextCurrent()
+	gl.UseProgram(b.shader)
+	gl.Enable(gl.BLEND)
+	// Img is an image.RGBA, its alpha is premultiplied. Like the Software backend
+	// blends it, and the text on the gpu lands on it like it would in Img.
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+
+	// the rows of the rectangles are inside the rows of frame
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, int32(frame.Stride/4))
+	for _, r := range dirty {
+		gl.TextureSubImage2D(
+			b.texture,
+			0,
+			int32(r.Min.X),
+			int32(r.Min.Y),
+			int32(r.Dx()),
+			int32(r.Dy()),
+			gl.RGBA,
+			gl.UNSIGNED_BYTE,
+			gl.Ptr(frame.Pix[frame.PixOffset(r.Min.X, r.Min.Y):]))
+	}
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+
+	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthFunc(gl.LESS)
+
+	{
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, b.texture)
+		gl.BindVertexArray(b.quadVAO)
+		gl.DrawArrays(gl.TRIANGLES, 0, 6*2*3)
+	}
+	b.presentGlyphs()
+
+	gl.Disable(gl.BLEND)
+	gl.Disable(gl.DEPTH_TEST)
+}
+
+// converts to the float components gl.ClearColor() wants
+func glColor(c color.RGBA) (r, g, b, a float32) {
+	return float32(c.R) / 255, float32(c.G) / 255, float32(c.B) / 255, float32(c.A) / 255
+}
+
+//
+// @Speed: Is a map efficient enough?
+//
+
+var buttons = map[glfw.MouseButton]Button{
+	glfw.MouseButtonLeft:   MouseLeft,
+	glfw.MouseButtonRight:  MouseRight,
+	glfw.MouseButtonMiddle: MouseMiddle,
+}
+
+var keys = map[glfw.Key]Key{
+	glfw.KeyLeft:         Left,
+	glfw.KeyRight:        Right,
+	glfw.KeyUp:           Up,
+	glfw.KeyDown:         Down,
+	glfw.KeyEscape:       Escape,
+	glfw.KeySpace:        Space,
+	glfw.KeyBackspace:    Backspace,
+	glfw.KeyDelete:       Delete,
+	glfw.KeyEnter:        Enter,
+	glfw.KeyTab:          Tab,
+	glfw.KeyHome:         Home,
+	glfw.KeyEnd:          End,
+	glfw.KeyPageUp:       PageUp,
+	glfw.KeyPageDown:     PageDown,
+	glfw.KeyLeftShift:    Shift,
+	glfw.KeyRightShift:   Shift,
+	glfw.KeyLeftControl:  Ctrl,
+	glfw.KeyRightControl: Ctrl,
+	glfw.KeyLeftAlt:      Alt,
+	glfw.KeyRightAlt:     Alt,
+	glfw.KeyLeftSuper:    Super,
+	glfw.KeyRightSuper:   Super,
+	glfw.KeyInsert:       Insert,
+	glfw.KeyCapsLock:     CapsLock,
+	glfw.KeyScrollLock:   ScrollLock,
+	glfw.KeyNumLock:      NumLock,
+	glfw.KeyPrintScreen:  PrintScreen,
+	glfw.KeyPause:        Pause,
+	glfw.KeyMenu:         Menu,
+	glfw.KeyA:            KeyA,
+	glfw.KeyB:            KeyB,
+	glfw.KeyC:            KeyC,
+	glfw.KeyD:            KeyD,
+	glfw.KeyE:            KeyE,
+	glfw.KeyF:            KeyF,
+	glfw.KeyG:            KeyG,
+	glfw.KeyH:            KeyH,
+	glfw.KeyI:            KeyI,
+	glfw.KeyJ:            KeyJ,
+	glfw.KeyK:            KeyK,
+	glfw.KeyL:            KeyL,
+	glfw.KeyM:            KeyM,
+	glfw.KeyN:            KeyN,
+	glfw.KeyO:            KeyO,
+	glfw.KeyP:            KeyP,
+	glfw.KeyQ:            KeyQ,
+	glfw.KeyR:            KeyR,
+	glfw.KeyS:            KeyS,
+	glfw.KeyT:            KeyT,
+	glfw.KeyU:            KeyU,
+	glfw.KeyV:            KeyV,
+	glfw.KeyW:            KeyW,
+	glfw.KeyX:            KeyX,
+	glfw.KeyY:            KeyY,
+	glfw.KeyZ:            KeyZ,
+	glfw.Key0:            Key0,
+	glfw.Key1:            Key1,
+	glfw.Key2:            Key2,
+	glfw.Key3:            Key3,
+	glfw.Key4:            Key4,
+	glfw.Key5:            Key5,
+	glfw.Key6:            Key6,
+	glfw.Key7:            Key7,
+	glfw.Key8:            Key8,
+	glfw.Key9:            Key9,
+	glfw.KeyApostrophe:   Apostrophe,
+	glfw.KeyComma:        Comma,
+	glfw.KeyMinus:        Minus,
+	glfw.KeyPeriod:       Period,
+	glfw.KeySlash:        Slash,
+	glfw.KeySemicolon:    Semicolon,
+	glfw.KeyEqual:        Equal,
+	glfw.KeyLeftBracket:  LeftBracket,
+	glfw.KeyBackslash:    Backslash,
+	glfw.KeyRightBracket: RightBracket,
+	glfw.KeyGraveAccent:  GraveAccent,
+	glfw.KeyWorld1:       World1,
+	glfw.KeyWorld2:       World2,
+	glfw.KeyF1:           F1,
+	glfw.KeyF2:           F2,
+	glfw.KeyF3:           F3,
+	glfw.KeyF4:           F4,
+	glfw.KeyF5:           F5,
+	glfw.KeyF6:           F6,
+	glfw.KeyF7:           F7,
+	glfw.KeyF8:           F8,
+	glfw.KeyF9:           F9,
+	glfw.KeyF10:          F10,
+	glfw.KeyF11:          F11,
+	glfw.KeyF12:          F12,
+	glfw.KeyF13:          F13,
+	glfw.KeyF14:          F14,
+	glfw.KeyF15:          F15,
+	glfw.KeyF16:          F16,
+	glfw.KeyF17:          F17,
+	glfw.KeyF18:          F18,
+	glfw.KeyF19:          F19,
+	glfw.KeyF20:          F20,
+	glfw.KeyF21:          F21,
+	glfw.KeyF22:          F22,
+	glfw.KeyF23:          F23,
+	glfw.KeyF24:          F24,
+	glfw.KeyF25:          F25,
+	glfw.KeyKP0:          Kp0,
+	glfw.KeyKP1:          Kp1,
+	glfw.KeyKP2:          Kp2,
+	glfw.KeyKP3:          Kp3,
+	glfw.KeyKP4:          Kp4,
+	glfw.KeyKP5:          Kp5,
+	glfw.KeyKP6:          Kp6,
+	glfw.KeyKP7:          Kp7,
+	glfw.KeyKP8:          Kp8,
+	glfw.KeyKP9:          Kp9,
+	glfw.KeyKPDecimal:    KpDecimal,
+	glfw.KeyKPDivide:     KpDivide,
+	glfw.KeyKPMultiply:   KpMultiply,
+	glfw.KeyKPSubtract:   KpSubtract,
+	glfw.KeyKPAdd:        KpAdd,
+	glfw.KeyKPEnter:      KpEnter,
+	glfw.KeyKPEqual:      KpEqual,
+}
+
+func mods(m glfw.ModifierKey) Mods {
+	var result Mods
+	if m&glfw.ModShift != 0 {
+		result |= ModShift
+	}
+	if m&glfw.ModControl != 0 {
+		result |= ModCtrl
+	}
+	if m&glfw.ModAlt != 0 {
+		result |= ModAlt
+	}
+	if m&glfw.ModSuper != 0 {
+		result |= ModSuper
+	}
+	if m&glfw.ModCapsLock != 0 {
+		result |= ModCapsLock
+	}
+	if m&glfw.ModNumLock != 0 {
+		result |= ModNumLock
+	}
+	return result
+}
+
+// forwards the glfw callbacks to the event queue of the window
+func (b *glBackend) callbacksSetup() {
+	w, win := b.w, b.win
+
+	win.SetCursorPosCallback(func(_ *glfw.Window, x, y float64) {
+		w.Inject(Ev{
+			Kind:  MouMove,
+			Point: image.Pt(int(x), int(y)),
+		})
+	})
+
+	win.SetMouseButtonCallback(func(_ *glfw.Window, button glfw.MouseButton, action glfw.Action, mod glfw.ModifierKey) {
+		b, ok := buttons[button]
+		if !ok {
+			return
+		}
+		switch action {
+		case glfw.Press:
+			w.Inject(Ev{
+				Kind:   MouDown,
+				Point:  image.Pt(w.MouseX, w.MouseY),
+				Button: b,
+				Mods:   mods(mod),
+			})
+		case glfw.Release:
+			w.Inject(Ev{
+				Kind:   MouUp,
+				Point:  image.Pt(w.MouseX, w.MouseY),
+				Button: b,
+				Mods:   mods(mod),
+			})
+		}
+	})
+
+	win.SetScrollCallback(func(_ *glfw.Window, xoff, yoff float64) {
+		w.Inject(Ev{
+			Kind:  MouScroll,
+			Point: image.Pt(int(xoff), int(yoff)),
+		})
+	})
+
+	win.SetCharCallback(func(_ *glfw.Window, r rune) {
+		w.Inject(Ev{
+			Kind: RuneTyped,
+			Rune: r,
+			Mods: w.Mods,
+		})
+	})
+
+	// report CapsLock and NumLock in the mods too
+	win.SetInputMode(glfw.LockKeyMods, glfw.True)
+
+	win.SetKeyCallback(func(_ *glfw.Window, key glfw.Key, _ int, action glfw.Action, mod glfw.ModifierKey) {
+		k, ok := keys[key]
+		if !ok {
+			return
+		}
+		switch action {
+		case glfw.Press:
+			w.Inject(Ev{
+				Kind: KeyDown,
+				Key:  k,
+				Mods: mods(mod),
+			})
+		case glfw.Release:
+			w.Inject(Ev{
+				Kind: KeyUp,
+				Key:  k,
+				Mods: mods(mod),
+			})
+		case glfw.Repeat:
+			w.Inject(Ev{
+				Kind: KeyRepeat,
+				Key:  k,
+				Mods: mods(mod),
+			})
+		}
+	})
+
+	win.SetFramebufferSizeCallback(func(_ *glfw.Window, width, height int) {
+		// minimized, keep everything as it is
+		if width == 0 || height == 0 {
+			return
+		}
+		b.makeContextCurrent()
+		gl.Viewport(0, 0, int32(width), int32(height))
+		gl.DeleteTextures(1, &b.texture)
+		b.texture = newScreenTexture(width, height)
+		w.resized(width, height)
+	})
+
+	win.SetCloseCallback(func(_ *glfw.Window) {
+		w.Inject(Ev{
+			Kind: WinClose,
+		})
+	})
+}
+
+func (b *glBackend) openGLSetup() error {
+	var err error
+	var guiShaderSource = `
+		#version 420
+
+		in vec3 vert;
+		in vec2 vertTexCoord;
+		out vec2 fragTexCoord;
+
+		void main() {
+			fragTexCoord = vertTexCoord;
+			gl_Position = vec4(vert.xy, 0.0, 1.0);
+		}
+		#define FRAGMENT_SHADER
+		#version 420
+
+		uniform sampler2D tex;
+		in vec2 fragTexCoord;
+
+		out vec4 outputColor;
+
+		void main() {
+			outputColor = texture(tex, fragTexCoord);
+		}
+	`
+
+	b.shader, err = NewGLProgram(guiShaderSource)
+
+	if err != nil {
+		fmt.Print("\nERROR making GuiShader: ")
+		return err
+	}
+
+	width, height := b.win.GetFramebufferSize()
+	b.texture = newScreenTexture(width, height)
+
+	upLeft := image.Point{0, 0}
+	lowRight := image.Point{width, height}
+	b.w.Img = image.NewRGBA(image.Rectangle{upLeft, lowRight})
+
+	textureUniform := gl.GetUniformLocation(b.shader, gl.Str("tex\x00"))
+	gl.Uniform1i(textureUniform, 0)
+	gl.BindFragDataLocation(b.shader, 0, gl.Str("outputColor\x00"))
+
+	gl.GenVertexArrays(1, &b.quadVAO)
+	gl.BindVertexArray(b.quadVAO)
+
+	var vbo uint32
+	gl.GenBuffers(1, &vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(GuiQuad)*4, gl.Ptr(GuiQuad), gl.STATIC_DRAW)
+
+	vertAttrib := uint32(gl.GetAttribLocation(b.shader, gl.Str("vert\x00")))
+	gl.EnableVertexAttribArray(vertAttrib)
+	gl.VertexAttribPointerWithOffset(vertAttrib, 3, gl.FLOAT, false, 5*4, 0)
+
+	texCoordAttrib := uint32(gl.GetAttribLocation(b.shader, gl.Str("vertTexCoord\x00")))
+	gl.EnableVertexAttribArray(texCoordAttrib)
+	gl.VertexAttribPointerWithOffset(texCoordAttrib, 2, gl.FLOAT, false, 5*4, 3*4)
+
+	if err = b.glyphSetup(); err != nil {
+		fmt.Print("\nERROR making the glyph shader: ")
+		return err
+	}
+	return nil
+}
+
+func NewGLProgram(shaderSource string) (uint32, error) {
+
+	shaderSources := strings.Split(shaderSource, "#define FRAGMENT_SHADER")
+
+	if len(shaderSources) != 2 {
+		return 0, errors.New("Syntax Error: tomato style shader source needs `#define FRAGMENT_SHADER` that separates vertex/fragment shader! (this is because we only want one shader source file!)\n")
+	}
+
+	vertexShaderSource := shaderSources[0] + "\x00"
+	fragmentShaderSource := shaderSources[1] + "\x00"
+
+	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, err
+	}
+
+	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
+	if err != nil {
+		return 0, err
+	}
+
+	program := gl.CreateProgram()
+
+	gl.AttachShader(program, vertexShader)
+	gl.AttachShader(program, fragmentShader)
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+
+		return 0, fmt.Errorf("failed to link program: %v", log)
+	}
+
+	gl.DeleteShader(vertexShader)
+	gl.DeleteShader(fragmentShader)
+
+	return program, nil
+}
+
+func compileShader(source string, shaderType uint32) (uint32, error) {
+	shader := gl.CreateShader(shaderType)
+	csources, free := gl.Strs(source)
+
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+
+		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
+	}
+
+	return shader, nil
+}
+
+func newScreenTexture(width, height int) uint32 {
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	if rgba.Stride != rgba.Rect.Size().X*4 {
+		panic("unsupported stride")
+	}
+	draw.Draw(rgba, rgba.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 0}), image.Point{0, 0}, draw.Src)
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		gl.RGBA,
+		int32(rgba.Rect.Size().X),
+		int32(rgba.Rect.Size().Y),
+		0,
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		gl.Ptr(rgba.Pix))
+
+	return texture
+}
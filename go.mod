@@ -5,6 +5,7 @@ go 1.22
 require (
 	github.com/go-gl/gl v0.0.0-20231021071112-07e5d0ea2e71
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20240307211618-a69d953ea142
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
-	golang.org/x/image v0.15.0
+	github.com/go-text/typesetting v0.3.5
+	golang.org/x/image v0.23.0
+	golang.org/x/text v0.21.0
 )
@@ -0,0 +1,17 @@
+//go:build headless
+
+package tomato
+
+// there is no glfw without the gl backend, Win and Window.Win stay nil
+type glfwWindow struct{}
+
+func newDefaultBackend() Backend {
+	return &Software{}
+}
+
+func (w *Window) makeContextCurrent() {}
+
+func (w *Window) syncGLGlobals() {}
+
+// Shows what was drawn, only for the gl backend
+func (w *Window) SwapBuffers() {}
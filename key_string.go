@@ -25,15 +25,115 @@ func _() {
 	_ = x[Shift-14]
 	_ = x[Ctrl-15]
 	_ = x[Alt-16]
+	_ = x[Super-17]
+	_ = x[Insert-18]
+	_ = x[CapsLock-19]
+	_ = x[ScrollLock-20]
+	_ = x[NumLock-21]
+	_ = x[PrintScreen-22]
+	_ = x[Pause-23]
+	_ = x[Menu-24]
+	_ = x[KeyA-25]
+	_ = x[KeyB-26]
+	_ = x[KeyC-27]
+	_ = x[KeyD-28]
+	_ = x[KeyE-29]
+	_ = x[KeyF-30]
+	_ = x[KeyG-31]
+	_ = x[KeyH-32]
+	_ = x[KeyI-33]
+	_ = x[KeyJ-34]
+	_ = x[KeyK-35]
+	_ = x[KeyL-36]
+	_ = x[KeyM-37]
+	_ = x[KeyN-38]
+	_ = x[KeyO-39]
+	_ = x[KeyP-40]
+	_ = x[KeyQ-41]
+	_ = x[KeyR-42]
+	_ = x[KeyS-43]
+	_ = x[KeyT-44]
+	_ = x[KeyU-45]
+	_ = x[KeyV-46]
+	_ = x[KeyW-47]
+	_ = x[KeyX-48]
+	_ = x[KeyY-49]
+	_ = x[KeyZ-50]
+	_ = x[Key0-51]
+	_ = x[Key1-52]
+	_ = x[Key2-53]
+	_ = x[Key3-54]
+	_ = x[Key4-55]
+	_ = x[Key5-56]
+	_ = x[Key6-57]
+	_ = x[Key7-58]
+	_ = x[Key8-59]
+	_ = x[Key9-60]
+	_ = x[Apostrophe-61]
+	_ = x[Comma-62]
+	_ = x[Minus-63]
+	_ = x[Period-64]
+	_ = x[Slash-65]
+	_ = x[Semicolon-66]
+	_ = x[Equal-67]
+	_ = x[LeftBracket-68]
+	_ = x[Backslash-69]
+	_ = x[RightBracket-70]
+	_ = x[GraveAccent-71]
+	_ = x[World1-72]
+	_ = x[World2-73]
+	_ = x[F1-74]
+	_ = x[F2-75]
+	_ = x[F3-76]
+	_ = x[F4-77]
+	_ = x[F5-78]
+	_ = x[F6-79]
+	_ = x[F7-80]
+	_ = x[F8-81]
+	_ = x[F9-82]
+	_ = x[F10-83]
+	_ = x[F11-84]
+	_ = x[F12-85]
+	_ = x[F13-86]
+	_ = x[F14-87]
+	_ = x[F15-88]
+	_ = x[F16-89]
+	_ = x[F17-90]
+	_ = x[F18-91]
+	_ = x[F19-92]
+	_ = x[F20-93]
+	_ = x[F21-94]
+	_ = x[F22-95]
+	_ = x[F23-96]
+	_ = x[F24-97]
+	_ = x[F25-98]
+	_ = x[Kp0-99]
+	_ = x[Kp1-100]
+	_ = x[Kp2-101]
+	_ = x[Kp3-102]
+	_ = x[Kp4-103]
+	_ = x[Kp5-104]
+	_ = x[Kp6-105]
+	_ = x[Kp7-106]
+	_ = x[Kp8-107]
+	_ = x[Kp9-108]
+	_ = x[KpDecimal-109]
+	_ = x[KpDivide-110]
+	_ = x[KpMultiply-111]
+	_ = x[KpSubtract-112]
+	_ = x[KpAdd-113]
+	_ = x[KpEnter-114]
+	_ = x[KpEqual-115]
 }
 
-const _Key_name = "LeftRightUpDownEscapeSpaceBackspaceDeleteEnterTabHomeEndPageUpPageDownShiftCtrlAlt"
+const _Key_name = "LeftRightUpDownEscapeSpaceBackspaceDeleteEnterTabHomeEndPageUpPageDownShiftCtrlAltSuperInsertCapsLockScrollLockNumLockPrintScreenPauseMenuKeyAKeyBKeyCKeyDKeyEKeyFKeyGKeyHKeyIKeyJKeyKKeyLKeyMKeyNKeyOKeyPKeyQKeyRKeySKeyTKeyUKeyVKeyWKeyXKeyYKeyZKey0Key1Key2Key3Key4Key5Key6Key7Key8Key9ApostropheCommaMinusPeriodSlashSemicolonEqualLeftBracketBackslashRightBracketGraveAccentWorld1World2F1F2F3F4F5F6F7F8F9F10F11F12F13F14F15F16F17F18F19F20F21F22F23F24F25Kp0Kp1Kp2Kp3Kp4Kp5Kp6Kp7Kp8Kp9KpDecimalKpDivideKpMultiplyKpSubtractKpAddKpEnterKpEqual"
 
-var _Key_index = [...]uint8{0, 4, 9, 11, 15, 21, 26, 35, 41, 46, 49, 53, 56, 62, 70, 75, 79, 82}
+var _Key_index = [...]uint16{0, 4, 9, 11, 15, 21, 26, 35, 41, 46, 49, 53, 56, 62, 70, 75, 79, 82, 87, 93, 101, 111, 118, 129, 134, 138, 142, 146, 150, 154, 158, 162, 166, 170, 174, 178, 182, 186, 190, 194, 198, 202, 206, 210, 214, 218, 222, 226, 230, 234, 238, 242, 246, 250, 254, 258, 262, 266, 270, 274, 278, 282, 292, 297, 302, 308, 313, 322, 327, 338, 347, 359, 370, 376, 382, 384, 386, 388, 390, 392, 394, 396, 398, 400, 403, 406, 409, 412, 415, 418, 421, 424, 427, 430, 433, 436, 439, 442, 445, 448, 451, 454, 457, 460, 463, 466, 469, 472, 475, 478, 487, 495, 505, 515, 520, 527, 534}
 
 func (i Key) String() string {
-	if i >= Key(len(_Key_index)-1) {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_Key_index)-1 {
 		return "Key(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _Key_name[_Key_index[i]:_Key_index[i+1]]
+	return _Key_name[_Key_index[idx]:_Key_index[idx+1]]
 }
@@ -0,0 +1,325 @@
+/*
+   Keyboard shortcuts: named actions bound to key chords.
+
+   Instead of a switch over event.Key in every program, declare the actions
+   once and let the Keymap translate the events:
+
+       km := tomato.NewKeymap()
+       km.Bind("save", "Ctrl+S")
+       km.Bind("palette", "Ctrl+Shift+P")
+       km.Bind("top", "g g") // a sequence, g pressed twice
+
+       case event := <-tomato.Events():
+           switch action, _ := km.Handle(event); action {
+           case "save":
+               ...
+           }
+
+   A binding is a space separated sequence of chords, a chord is modifiers
+   and a key joined by '+'. Key names are the ones of the Key constants,
+   letters and digits can be written without the "Key" prefix ("S", "5").
+   Case doesn't matter.
+
+   The bindings can be saved to and loaded from a json file
+   ({"save": "Ctrl+S", ...}), so users can customize them.
+*/
+
+package tomato
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// modifiers that are compared, the lock keys are ignored
+const chordMods = ModShift | ModCtrl | ModAlt | ModSuper
+
+type Chord struct {
+	Mods Mods
+	Key  Key
+}
+
+func (c Chord) String() string {
+	name := keyName(c.Key)
+	if c.Mods&chordMods == 0 {
+		return name
+	}
+	return (c.Mods & chordMods).String() + "+" + name
+}
+
+// A sequence of chords, usually only one
+type Binding []Chord
+
+func (b Binding) String() string {
+	strs := make([]string, len(b))
+	for i, c := range b {
+		strs[i] = c.String()
+	}
+	return strings.Join(strs, " ")
+}
+
+func (b Binding) Equal(o Binding) bool {
+	return len(b) == len(o) && b.hasPrefix(o)
+}
+
+func (b Binding) hasPrefix(prefix Binding) bool {
+	if len(prefix) > len(b) {
+		return false
+	}
+	for i := range prefix {
+		if b[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Parses bindings like "Ctrl+Shift+P", "F5" or "g g".
+func ParseBinding(s string) (Binding, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty binding")
+	}
+
+	var b Binding
+	for _, field := range fields {
+		var c Chord
+		parts := strings.Split(field, "+")
+		for i, part := range parts {
+			last := i == len(parts)-1
+			if !last {
+				m, ok := modByName(part)
+				if !ok {
+					return nil, fmt.Errorf("unknown modifier %q in binding %q", part, s)
+				}
+				c.Mods |= m
+				continue
+			}
+			k, ok := keyByName(part)
+			if !ok {
+				return nil, fmt.Errorf("unknown key %q in binding %q", part, s)
+			}
+			c.Key = k
+		}
+		b = append(b, c)
+	}
+	return b, nil
+}
+
+func modByName(name string) (Mods, bool) {
+	switch strings.ToLower(name) {
+	case "ctrl", "control":
+		return ModCtrl, true
+	case "shift":
+		return ModShift, true
+	case "alt", "option":
+		return ModAlt, true
+	case "super", "cmd", "meta", "win":
+		return ModSuper, true
+	}
+	return 0, false
+}
+
+var (
+	keysByName     map[string]Key // lower case name -> Key
+	keysByNameOnce sync.Once      // bindings are parsed on many goroutines
+)
+
+func keyByName(name string) (Key, bool) {
+	keysByNameOnce.Do(func() {
+		keysByName = make(map[string]Key)
+		for k := Key(0); !strings.HasPrefix(k.String(), "Key("); k++ {
+			keysByName[strings.ToLower(k.String())] = k
+			keysByName[strings.ToLower(keyName(k))] = k
+		}
+		keysByName["esc"] = Escape
+		keysByName["return"] = Enter
+	})
+	k, ok := keysByName[strings.ToLower(name)]
+	return k, ok
+}
+
+// "A" instead of "KeyA"
+func keyName(k Key) string {
+	name := k.String()
+	if len(name) == 4 && strings.HasPrefix(name, "Key") {
+		return name[3:]
+	}
+	return name
+}
+
+func isModifierKey(k Key) bool {
+	return k == Shift || k == Ctrl || k == Alt || k == Super
+}
+
+// Returned by Bind when a binding would shadow another one
+type BindingConflict struct {
+	Action, Other string
+	Binding       Binding
+}
+
+func (c *BindingConflict) Error() string {
+	return fmt.Sprintf("binding %v of %q conflicts with %q", c.Binding, c.Action, c.Other)
+}
+
+type Keymap struct {
+	bindings map[string]Binding // action -> binding
+
+	// how long to wait for the next chord of a sequence
+	Timeout time.Duration
+
+	pending     Binding // chords of a sequence typed so far
+	lastPressed time.Time
+}
+
+func NewKeymap() *Keymap {
+	return &Keymap{
+		bindings: make(map[string]Binding),
+		Timeout:  time.Second,
+	}
+}
+
+// Binds action to binding, an existing binding of action is replaced.
+// Fails with a *BindingConflict if another action has the same binding
+// or one of the two is the start of the other (like "g" and "g g").
+func (km *Keymap) Bind(action, binding string) error {
+	b, err := ParseBinding(binding)
+	if err != nil {
+		return err
+	}
+	if err := km.conflict(action, b, km.bindings); err != nil {
+		return err
+	}
+	km.bindings[action] = b
+	km.pending = nil
+	return nil
+}
+
+func (km *Keymap) conflict(action string, b Binding, bindings map[string]Binding) error {
+	for other, ob := range bindings {
+		if other == action {
+			continue
+		}
+		if b.hasPrefix(ob) || ob.hasPrefix(b) {
+			return &BindingConflict{Action: action, Other: other, Binding: b}
+		}
+	}
+	return nil
+}
+
+func (km *Keymap) Unbind(action string) {
+	delete(km.bindings, action)
+	km.pending = nil
+}
+
+// The binding of action, nil if it has none
+func (km *Keymap) Binding(action string) Binding {
+	return km.bindings[action]
+}
+
+// All actions with a binding, sorted
+func (km *Keymap) Actions() []string {
+	actions := make([]string, 0, len(km.bindings))
+	for action := range km.bindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// Feed it the events, it returns the action when its binding was completed.
+func (km *Keymap) Handle(ev Ev) (string, bool) {
+	if ev.Kind != KeyDown || isModifierKey(ev.Key) {
+		return "", false
+	}
+
+	now := time.Now()
+	if now.Sub(km.lastPressed) > km.Timeout {
+		km.pending = nil
+	}
+	km.lastPressed = now
+
+	chords := append(km.pending, Chord{Mods: ev.Mods & chordMods, Key: ev.Key})
+	km.pending = nil
+
+	// When a sequence broke off, the chords typed for it may end with the
+	// start of another one ("a b" then "b c"), the longest end that is wins.
+	for i := range chords {
+		action, ok, partial := km.match(chords[i:])
+		if ok {
+			return action, true
+		}
+		if partial {
+			km.pending = chords[i:]
+			break
+		}
+	}
+	return "", false
+}
+
+// the action bound to chords, partial is true if they are the start of a sequence
+func (km *Keymap) match(chords Binding) (action string, ok, partial bool) {
+	for action, b := range km.bindings {
+		if b.Equal(chords) {
+			return action, true, false
+		}
+		if b.hasPrefix(chords) {
+			partial = true
+		}
+	}
+	return "", false, partial
+}
+
+// Writes the bindings as json object, action -> binding
+func (km *Keymap) Save(w io.Writer) error {
+	strs := make(map[string]string, len(km.bindings))
+	for action, b := range km.bindings {
+		strs[action] = b.String()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(strs)
+}
+
+// Reads bindings written by Save. They are merged into the existing ones,
+// overriding the bindings of the same actions. On an error nothing is changed.
+func (km *Keymap) Load(r io.Reader) error {
+	var strs map[string]string
+	if err := json.NewDecoder(r).Decode(&strs); err != nil {
+		return err
+	}
+
+	bindings := make(map[string]Binding, len(km.bindings))
+	for action, b := range km.bindings {
+		if _, ok := strs[action]; !ok {
+			bindings[action] = b
+		}
+	}
+
+	// sorted, to report the same conflict every time
+	actions := make([]string, 0, len(strs))
+	for action := range strs {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	for _, action := range actions {
+		b, err := ParseBinding(strs[action])
+		if err != nil {
+			return fmt.Errorf("action %q: %w", action, err)
+		}
+		if err := km.conflict(action, b, bindings); err != nil {
+			return err
+		}
+		bindings[action] = b
+	}
+
+	km.bindings = bindings
+	km.pending = nil
+	return nil
+}
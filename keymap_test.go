@@ -0,0 +1,154 @@
+package tomato
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Binding
+		err  bool
+	}{
+		{s: "F5", want: Binding{{Key: F5}}},
+		{s: "S", want: Binding{{Key: KeyS}}},
+		{s: "keys", want: Binding{{Key: KeyS}}},
+		{s: "5", want: Binding{{Key: Key5}}},
+		{s: "Ctrl+S", want: Binding{{ModCtrl, KeyS}}},
+		{s: "control+shift+p", want: Binding{{ModCtrl | ModShift, KeyP}}},
+		{s: "Cmd+Option+Esc", want: Binding{{ModSuper | ModAlt, Escape}}},
+		{s: "Return", want: Binding{{Key: Enter}}},
+		{s: "g g", want: Binding{{Key: KeyG}, {Key: KeyG}}},
+		{s: "  Ctrl+K   Ctrl+C ", want: Binding{{ModCtrl, KeyK}, {ModCtrl, KeyC}}},
+		{s: "", err: true},
+		{s: "   ", err: true},
+		{s: "Hyper+S", err: true},
+		{s: "Ctrl+Nope", err: true},
+		{s: "Ctrl+", err: true},
+		{s: "S+Ctrl", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBinding(tt.s)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseBinding(%q) error %v, want error %v", tt.s, err, tt.err)
+			continue
+		}
+		if !tt.err && !got.Equal(tt.want) {
+			t.Errorf("ParseBinding(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+// what Binding.String writes ParseBinding reads
+func TestBindingString(t *testing.T) {
+	for _, s := range []string{"F5", "S", "Ctrl+S", "Shift+Ctrl+P", "G G", "Ctrl+K Ctrl+C"} {
+		b, err := ParseBinding(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := ParseBinding(b.String())
+		if err != nil || !again.Equal(b) {
+			t.Errorf("%q: String() gave %q, that parses to %v, %v", s, b.String(), again, err)
+		}
+	}
+}
+
+func TestKeymapLoad(t *testing.T) {
+	tests := []struct {
+		json string
+		want map[string]string // action -> binding after Load
+		err  bool
+	}{
+		{json: `{}`, want: map[string]string{"save": "Ctrl+S", "top": "G G"}},
+		{json: `{"save": "Ctrl+W"}`, want: map[string]string{"save": "Ctrl+W", "top": "G G"}},
+		{json: `{"quit": "Ctrl+Q"}`, want: map[string]string{"save": "Ctrl+S", "top": "G G", "quit": "Ctrl+Q"}},
+		{json: `{"top": "G"}`, want: map[string]string{"save": "Ctrl+S", "top": "G"}},
+		{json: `{"quit": "Ctrl+S"}`, err: true},
+		{json: `{"quit": "G"}`, err: true},
+		{json: `{"save": "Ctrl+Nope"}`, err: true},
+		{json: `{"save": 5}`, err: true},
+		{json: `[`, err: true},
+	}
+	for _, tt := range tests {
+		km := NewKeymap()
+		km.Bind("save", "Ctrl+S")
+		km.Bind("top", "g g")
+
+		err := km.Load(strings.NewReader(tt.json))
+		if (err != nil) != tt.err {
+			t.Errorf("Load(%v) error %v, want error %v", tt.json, err, tt.err)
+			continue
+		}
+		want := tt.want
+		if tt.err {
+			// nothing changed
+			want = map[string]string{"save": "Ctrl+S", "top": "G G"}
+		}
+		if len(km.Actions()) != len(want) {
+			t.Errorf("Load(%v) gave the actions %v", tt.json, km.Actions())
+		}
+		for action, s := range want {
+			if got := km.Binding(action).String(); got != s {
+				t.Errorf("Load(%v): %v is bound to %q, want %q", tt.json, action, got, s)
+			}
+		}
+	}
+}
+
+func TestKeymapHandle(t *testing.T) {
+	tests := []struct {
+		name     string
+		bindings map[string]string
+		keys     string // chords like in a binding, "|" waits longer than the Timeout, "<ctrl>" is the Ctrl key alone
+		mods     Mods   // also held for every key
+		want     []string
+	}{
+		{"chord", map[string]string{"save": "Ctrl+S"}, "Ctrl+S S Ctrl+Shift+S Ctrl+S", 0, []string{"save", "", "", "save"}},
+		{"lock keys don't count", map[string]string{"save": "Ctrl+S"}, "Ctrl+S", ModCapsLock | ModNumLock, []string{"save"}},
+		{"sequence", map[string]string{"top": "g g", "bottom": "Shift+G"}, "g g Shift+G g g", 0, []string{"", "top", "bottom", "", "top"}},
+		{"sequence with mods", map[string]string{"comment": "Ctrl+K Ctrl+C"}, "Ctrl+K C Ctrl+K Ctrl+C", 0, []string{"", "", "", "comment"}},
+		{"modifier keys wait", map[string]string{"comment": "Ctrl+K Ctrl+C"}, "Ctrl+K <ctrl> Ctrl+C", 0, []string{"", "", "comment"}},
+		{"timeout", map[string]string{"top": "g g"}, "g | g g", 0, []string{"", "", "top"}},
+		{"broken off by an action", map[string]string{"top": "g g", "next": "n"}, "g n g g", 0, []string{"", "next", "", "top"}},
+		{"broken off by another key", map[string]string{"top": "g g"}, "g x g g", 0, []string{"", "", "", "top"}},
+		{"another sequence in it", map[string]string{"abc": "a b c", "bd": "b d"}, "a b d", 0, []string{"", "", "bd"}},
+		{"another sequence started in it", map[string]string{"abc": "a b c", "bcd": "b c d"}, "a b x b c d", 0, []string{"", "", "", "", "", "bcd"}},
+		{"the same key again", map[string]string{"abc": "a b c"}, "a a b c", 0, []string{"", "", "", "abc"}},
+	}
+	for _, tt := range tests {
+		km := NewKeymap()
+		for action, b := range tt.bindings {
+			if err := km.Bind(action, b); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var got []string
+		for _, key := range strings.Fields(tt.keys) {
+			ev := Ev{Kind: KeyDown, Mods: tt.mods}
+			switch key {
+			case "|":
+				km.lastPressed = time.Now().Add(-2 * km.Timeout)
+				continue
+			case "<ctrl>":
+				ev.Key, ev.Mods = Ctrl, ev.Mods|ModCtrl
+			default:
+				b, err := ParseBinding(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ev.Key, ev.Mods = b[0].Key, ev.Mods|b[0].Mods
+			}
+			action, ok := km.Handle(ev)
+			if ok != (action != "") {
+				t.Errorf("%v: Handle gave %q, %v", tt.name, action, ok)
+			}
+			got = append(got, action)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: %q gave %q, want %q", tt.name, tt.keys, got, tt.want)
+		}
+	}
+}
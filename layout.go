@@ -0,0 +1,272 @@
+/*
+   Layouts place the Ui elements one after the other.
+
+   Vertical   elements below each other, as wide as the layout
+   Horizontal elements next to each other, as high as the layout
+   Grid       rows of Columns equally wide cells
+
+   Layouts can be nested, SubLayout takes the next cell of the active layout
+   and makes the new layout active until EndLayout():
+
+       tomato.Layout(0, tomato.Vertical, image.Rect(0, 0, 400, 600))
+       tomato.TextButton(0, "Title", nil)
+       tomato.SubLayout(1, tomato.Horizontal, tomato.Size{0, 56})
+           tomato.TextButton(0, "Ok", nil)
+           tomato.TextButton(1, "Cancel", nil)
+       tomato.EndLayout()
+
+   The ids of all layouts, nested or not, come from the same range
+   starting at 0. The alignment uses the size of the content of the
+   previous frame, so it takes a frame to settle.
+*/
+
+package tomato
+
+import (
+	"image"
+)
+
+type Orientation uint8
+
+const (
+	Vertical Orientation = iota
+	Horizontal
+	Grid
+)
+
+// Where the elements go along the orientation if there is space left
+type Alignment uint8
+
+const (
+	AlignStart Alignment = iota
+	AlignCenter
+	AlignEnd
+)
+
+type LayoutStyle struct {
+	Spacing int       // between the elements
+	Padding int       // between the border of the layout and the elements
+	Align   Alignment // along the orientation (the rows for Grid)
+	Columns int       // only for Grid
+}
+
+// A Spacing of 0 here means the Spacing of the current Style
+var DefaultLayoutStyle = LayoutStyle{
+	Columns: 2,
+}
+
+// Which window edges a layout sticks to when the window is resized.
+// The zero value keeps it at the top left.
+type Anchor uint8
+
+const (
+	AnchorRight Anchor = 1 << iota
+	AnchorBottom
+	AnchorBottomRight = AnchorRight | AnchorBottom
+)
+
+type layout struct {
+	Ori     Orientation
+	Place   image.Rectangle
+	Elems   map[ID]*button
+	NextPos image.Point
+	Anchor  Anchor
+	Style   LayoutStyle
+	Scroll  int // of scroll panels, in pixels from the top
+
+	view       image.Rectangle // scroll panels: the visible part
+	start      image.Point     // where the first element of this frame went
+	column     int             // Grid: of the next element
+	rowHeight  int             // Grid: of the current row
+	extent     int             // size of the content along the orientation, this frame
+	lastExtent int             // and the previous one, for the alignment
+}
+
+func Layout(id int, orientation Orientation, place image.Rectangle) {
+	if id >= len(ui_frame.Layouts) {
+		newLayout(id, orientation, place)
+	}
+	ui_frame.Active = id
+	ui_frame.stack = append(ui_frame.stack[:0], id)
+}
+
+func newLayout(id int, orientation Orientation, place image.Rectangle) {
+	if id != len(ui_frame.Layouts) {
+		panic("Need To call Layout(...) with ids starting from 0 in increaing manner.")
+	}
+	ui_frame.Layouts = append(ui_frame.Layouts, layout{
+		Ori:   orientation,
+		Place: place,
+		Elems: make(map[ID]*button),
+		Style: DefaultLayoutStyle,
+	})
+	if DefaultLayoutStyle.Spacing == 0 {
+		ui_frame.Layouts[id].Style.Spacing = ui_frame.style().Spacing
+	}
+	ui_frame.Layouts[id].restart()
+	ui_frame.restoreLayout(id)
+}
+
+// Starts a layout in the next cell of the active one, it is active until EndLayout().
+// A zero component of size fills the cell along that axis, as far as the parent allows.
+func SubLayout(id int, orientation Orientation, size Size) {
+	if len(ui_frame.stack) == 0 {
+		panic("\ntomato ERROR: call Layout(...) before SubLayout(...)\n")
+	}
+	parent := &ui_frame.Layouts[ui_frame.Active]
+	if size.Y == 0 && parent.Ori != Horizontal {
+		size.Y = widgetHeight(ui_frame.DefaultTheme.FontFace)
+	}
+	place := parent.next(size)
+
+	if id >= len(ui_frame.Layouts) {
+		newLayout(id, orientation, place)
+	}
+	// the parent decides where it goes, every frame
+	lay := &ui_frame.Layouts[id]
+	lay.Ori = orientation
+	if lay.Place != place {
+		lay.Place = place
+		lay.restart()
+	}
+
+	ui_frame.Active = id
+	ui_frame.stack = append(ui_frame.stack, id)
+}
+
+// Goes back to the layout that was active before SubLayout()
+func EndLayout() {
+	if len(ui_frame.stack) < 2 {
+		panic("\ntomato ERROR: EndLayout() without SubLayout(...)\n")
+	}
+	ui_frame.stack = ui_frame.stack[:len(ui_frame.stack)-1]
+	ui_frame.Active = ui_frame.stack[len(ui_frame.stack)-1]
+}
+
+// set spacing, padding and alignment of the active layout
+func StyleLayout(style LayoutStyle) {
+	lay := &ui_frame.Layouts[ui_frame.Active]
+	if lay.Style != style {
+		lay.Style = style
+		lay.restart()
+	}
+}
+
+// anchor the current layout to window edges
+func AnchorLayout(anchor Anchor) {
+	ui_frame.Layouts[ui_frame.Active].Anchor = anchor
+}
+
+// moves the anchored layouts after the window changed its size from old to new
+func (frame *Ui_Frame) reanchor(old, new Size) {
+	delta := new.Sub(old)
+	for i := range frame.Layouts {
+		lay := &frame.Layouts[i]
+		var move image.Point
+		if lay.Anchor&AnchorRight != 0 {
+			move.X = delta.X
+		}
+		if lay.Anchor&AnchorBottom != 0 {
+			move.Y = delta.Y
+		}
+		lay.Place = lay.Place.Add(move)
+		lay.NextPos = lay.NextPos.Add(move)
+		lay.start = lay.start.Add(move)
+	}
+}
+
+// the Place without the padding
+func (lay *layout) inner() image.Rectangle {
+	return lay.Place.Inset(lay.Style.Padding)
+}
+
+// called at the end of the frame
+func (lay *layout) endFrame() {
+	lay.lastExtent = lay.extent
+	lay.restart()
+
+	// forget the widgets that were not there this frame
+	for id, b := range lay.Elems {
+		if !b.used {
+			delete(lay.Elems, id)
+			continue
+		}
+		b.used = false
+	}
+}
+
+// puts the next position back to the start
+func (lay *layout) restart() {
+	inner := lay.inner()
+
+	free := 0
+	switch lay.Ori {
+	case Horizontal:
+		free = inner.Dx() - lay.lastExtent
+	default:
+		free = inner.Dy() - lay.lastExtent
+	}
+	offset := 0
+	if free > 0 {
+		switch lay.Style.Align {
+		case AlignCenter:
+			offset = free / 2
+		case AlignEnd:
+			offset = free
+		}
+	}
+
+	lay.start = inner.Min
+	if lay.Ori == Horizontal {
+		lay.start.X += offset
+	} else {
+		lay.start.Y += offset
+	}
+	lay.NextPos = lay.start
+	lay.column = 0
+	lay.rowHeight = 0
+	lay.extent = 0
+}
+
+// Returns where the next element goes and moves on.
+// A zero component of want fills the layout along that axis.
+func (lay *layout) next(want Size) image.Rectangle {
+	inner := lay.inner()
+	spacing := lay.Style.Spacing
+	var r image.Rectangle
+
+	switch lay.Ori {
+	case Vertical:
+		if want.X == 0 || want.X > inner.Dx() {
+			want.X = inner.Dx()
+		}
+		r = image.Rectangle{lay.NextPos, lay.NextPos.Add(want)}
+		lay.NextPos.Y += want.Y + spacing
+		lay.extent = r.Max.Y - lay.start.Y
+
+	case Horizontal:
+		if want.Y == 0 || want.Y > inner.Dy() {
+			want.Y = inner.Dy()
+		}
+		r = image.Rectangle{lay.NextPos, lay.NextPos.Add(want)}
+		lay.NextPos.X += want.X + spacing
+		lay.extent = r.Max.X - lay.start.X
+
+	case Grid:
+		columns := Max(lay.Style.Columns, 1)
+		cellW := (inner.Dx() - spacing*(columns-1)) / columns
+		want.X = cellW
+		pos := image.Pt(inner.Min.X+lay.column*(cellW+spacing), lay.NextPos.Y)
+		r = image.Rectangle{pos, pos.Add(want)}
+		lay.rowHeight = Max(lay.rowHeight, want.Y)
+		lay.extent = r.Max.Y - lay.start.Y
+
+		lay.column++
+		if lay.column == columns {
+			lay.column = 0
+			lay.NextPos.Y += lay.rowHeight + spacing
+			lay.rowHeight = 0
+		}
+	}
+	return r
+}
@@ -0,0 +1,30 @@
+// Code generated by "stringer -type=PadAxis"; DO NOT EDIT.
+
+package tomato
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[AxisLeftX-0]
+	_ = x[AxisLeftY-1]
+	_ = x[AxisRightX-2]
+	_ = x[AxisRightY-3]
+	_ = x[AxisLeftTrigger-4]
+	_ = x[AxisRightTrigger-5]
+	_ = x[padAxisCount-6]
+}
+
+const _PadAxis_name = "AxisLeftXAxisLeftYAxisRightXAxisRightYAxisLeftTriggerAxisRightTriggerpadAxisCount"
+
+var _PadAxis_index = [...]uint8{0, 9, 18, 28, 38, 53, 69, 81}
+
+func (i PadAxis) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_PadAxis_index)-1 {
+		return "PadAxis(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _PadAxis_name[_PadAxis_index[idx]:_PadAxis_index[idx+1]]
+}
@@ -0,0 +1,39 @@
+// Code generated by "stringer -type=PadButton"; DO NOT EDIT.
+
+package tomato
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[PadA-0]
+	_ = x[PadB-1]
+	_ = x[PadX-2]
+	_ = x[PadY-3]
+	_ = x[PadLeftBumper-4]
+	_ = x[PadRightBumper-5]
+	_ = x[PadBack-6]
+	_ = x[PadStart-7]
+	_ = x[PadGuide-8]
+	_ = x[PadLeftThumb-9]
+	_ = x[PadRightThumb-10]
+	_ = x[PadDpadUp-11]
+	_ = x[PadDpadRight-12]
+	_ = x[PadDpadDown-13]
+	_ = x[PadDpadLeft-14]
+	_ = x[padButtonCount-15]
+}
+
+const _PadButton_name = "PadAPadBPadXPadYPadLeftBumperPadRightBumperPadBackPadStartPadGuidePadLeftThumbPadRightThumbPadDpadUpPadDpadRightPadDpadDownPadDpadLeftpadButtonCount"
+
+var _PadButton_index = [...]uint8{0, 4, 8, 12, 16, 29, 43, 50, 58, 66, 78, 91, 100, 112, 123, 134, 148}
+
+func (i PadButton) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_PadButton_index)-1 {
+		return "PadButton(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _PadButton_name[_PadButton_index[idx]:_PadButton_index[idx+1]]
+}
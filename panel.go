@@ -0,0 +1,257 @@
+/*
+   Floating panels, little windows in the window with a title bar:
+
+       if tomato.BeginPanel(0, "Inspector", image.Rect(20, 20, 320, 400), &showInspector) {
+           tomato.Checkbox(0, "Wireframe", &wireframe, nil)
+           ...
+       }
+       tomato.EndPanel() // always, also if BeginPanel returned false
+
+   BeginPanel returns false if the panel is closed or collapsed, then skip its
+   widgets. The content is a vertical layout, its id comes from the same range
+   as the ones of the layouts.
+
+   The title bar moves the panel, the edges resize it, the button on the
+   left collapses it and the one on the right closes it (sets *open to false,
+   without open there is none). A click brings a panel to the front.
+   The place is only used the first time, after that the panel remembers
+   where it is by its id.
+
+   Panels are drawn over everything else and only the front one under the mouse
+   gets it. WantsMouse() tells if the mouse is the Ui's right now.
+*/
+
+package tomato
+
+import (
+	"image"
+	"strconv"
+
+	"golang.org/x/image/font"
+)
+
+const PANEL_GRIP int = 6 // width of the edges that resize a panel
+
+type panel struct {
+	Rect      image.Rectangle
+	Collapsed bool
+
+	z     int  // bigger is in front, also the draw layer
+	shown bool // in this frame
+
+	open        bool // for SaveUi
+	restoreOpen bool // LoadUi wants to set *open
+}
+
+// what EndPanel() has to undo
+type panelEntry struct {
+	p       *panel // nil if the panel is closed
+	content bool   // the layout of the panel is active
+	stack   []int
+	active  int
+}
+
+// which edges of a panel are dragged
+const (
+	edgeLeft = 1 << iota
+	edgeRight
+	edgeBottom
+)
+
+func BeginPanel(id int, title string, place image.Rectangle, open *bool) bool {
+	frame := ui_frame
+	p, ok := frame.panels[id]
+	if !ok {
+		frame.panelZ++
+		p = &panel{Rect: place, z: frame.panelZ}
+		frame.panels[id] = p
+		frame.restorePanel(id, p)
+	}
+	if p.restoreOpen {
+		p.restoreOpen = false
+		if open != nil {
+			*open = p.open
+		}
+	}
+	p.open = open == nil || *open
+	if id >= len(frame.Layouts) {
+		newLayout(id, Vertical, place)
+		frame.Layouts[id].Style.Padding = PANEL_GRIP
+	}
+
+	entry := panelEntry{stack: frame.stack, active: frame.Active}
+	if open != nil && !*open {
+		frame.panelStack = append(frame.panelStack, entry)
+		return false
+	}
+	entry.p = p
+	frame.panelStack = append(frame.panelStack, entry)
+	frame.inPanel = p
+	p.shown = true
+
+	theme, st := &frame.DefaultTheme, frame.style()
+	titleH := theme.FontFace.Metrics().Height.Ceil() + 2*Y_MARGIN
+	minSize := Size{4 * titleH, 2 * titleH}
+
+	// to the front
+	if current.MouseDownL && !frame.previousDown && frame.hovered == p {
+		frame.panelZ++
+		p.z = frame.panelZ
+	}
+	current.layer = p.z
+
+	bar := image.Rect(p.Rect.Min.X, p.Rect.Min.Y, p.Rect.Max.X, p.Rect.Min.Y+titleH)
+	collapse := image.Rect(bar.Min.X, bar.Min.Y, bar.Min.X+titleH, bar.Max.Y)
+	closeR := image.Rect(bar.Max.X-titleH, bar.Min.Y, bar.Max.X, bar.Max.Y)
+	moveID := hashID(idSeed, "#panel-move#"+strconv.Itoa(id))
+	resizeID := hashID(idSeed, "#panel-resize#"+strconv.Itoa(id))
+	mouse := image.Pt(current.MouseX, current.MouseY)
+
+	shape := p.Rect
+	if p.Collapsed {
+		shape = bar
+	}
+	if clickedIn(shape) {
+		frame.dragStart = mouse
+		frame.dragRect = p.Rect
+		edges := 0
+		if !p.Collapsed {
+			edges = panelEdges(p.Rect, mouse)
+		}
+		switch {
+		case mouseIn(collapse):
+			p.Collapsed = !p.Collapsed
+		case open != nil && mouseIn(closeR):
+			*open = false
+			p.open = false
+		case edges != 0:
+			frame.dragging = resizeID
+			frame.dragEdges = edges
+		case mouseIn(bar):
+			frame.dragging = moveID
+		}
+	}
+	if frame.dragging == moveID || frame.dragging == resizeID {
+		if !current.MouseDownL {
+			frame.dragging = 0
+		} else if frame.dragging == moveID {
+			p.Rect = frame.dragRect.Add(mouse.Sub(frame.dragStart))
+		} else {
+			p.Rect = resizePanel(frame.dragRect, frame.dragEdges, mouse.Sub(frame.dragStart), minSize)
+		}
+		bar = image.Rect(p.Rect.Min.X, p.Rect.Min.Y, p.Rect.Max.X, p.Rect.Min.Y+titleH)
+	}
+
+	// title bar
+	// @Speed rendered again every frame
+	barImg := image.NewRGBA(image.Rectangle{image.ZP, bar.Size()})
+	barColor := st.TitleBg
+	if p.z == frame.panelZ {
+		barColor = st.TitleActive
+	}
+	fillRect(barImg, barImg.Bounds(), barColor)
+	sign := "-"
+	if p.Collapsed {
+		sign = "+"
+	}
+	drawLabel(barImg, sign, (titleH-font.MeasureString(theme.FontFace, sign).Ceil())/2, theme)
+	drawLabel(barImg, title, titleH, theme)
+	if open != nil {
+		drawLabel(barImg, "x", bar.Dx()-titleH+(titleH-font.MeasureString(theme.FontFace, "x").Ceil())/2, theme)
+	}
+	ToDraw(bar, barImg)
+
+	if p.Collapsed || open != nil && !*open {
+		return false
+	}
+
+	body := image.Rect(p.Rect.Min.X, bar.Max.Y, p.Rect.Max.X, p.Rect.Max.Y)
+	ToDraw(body, image.NewUniform(st.PanelBg))
+
+	lay := &frame.Layouts[id]
+	lay.Ori = Vertical
+	if lay.Place != body {
+		lay.Place = body
+		lay.restart()
+	}
+
+	current.PushClip(body)
+	frame.stack = []int{id}
+	frame.Active = id
+	frame.panelStack[len(frame.panelStack)-1].content = true
+	return true
+}
+
+func EndPanel() {
+	frame := ui_frame
+	if len(frame.panelStack) == 0 {
+		panic("\ntomato ERROR: EndPanel() without BeginPanel(...)\n")
+	}
+	entry := frame.panelStack[len(frame.panelStack)-1]
+	frame.panelStack = frame.panelStack[:len(frame.panelStack)-1]
+
+	if entry.content {
+		current.PopClip()
+	}
+	frame.stack = entry.stack
+	frame.Active = entry.active
+
+	frame.inPanel = nil
+	current.layer = 0
+	if len(frame.panelStack) > 0 {
+		if outer := frame.panelStack[len(frame.panelStack)-1].p; outer != nil {
+			frame.inPanel = outer
+			current.layer = outer.z
+		}
+	}
+}
+
+// True if the mouse is over a panel or something of the Ui is dragged,
+// check it before using the mouse for your own stuff
+func WantsMouse() bool {
+	return ui_frame != nil && (ui_frame.hovered != nil || ui_frame.dragging != 0)
+}
+
+// finds the panel in front under the mouse, called by DrawUi
+func (frame *Ui_Frame) endPanels() {
+	mouse := image.Pt(current.MouseX, current.MouseY)
+	frame.hovered = nil
+	for _, p := range frame.panels {
+		shape := p.Rect
+		if p.Collapsed {
+			shape.Max.Y = shape.Min.Y + frame.DefaultTheme.FontFace.Metrics().Height.Ceil() + 2*Y_MARGIN
+		}
+		if p.shown && mouse.In(shape) && (frame.hovered == nil || p.z > frame.hovered.z) {
+			frame.hovered = p
+		}
+		p.shown = false
+	}
+}
+
+// the edges of r that are grabbed at mouse
+func panelEdges(r image.Rectangle, mouse image.Point) int {
+	edges := 0
+	if mouse.X < r.Min.X+PANEL_GRIP {
+		edges |= edgeLeft
+	}
+	if mouse.X >= r.Max.X-PANEL_GRIP {
+		edges |= edgeRight
+	}
+	if mouse.Y >= r.Max.Y-PANEL_GRIP {
+		edges |= edgeBottom
+	}
+	return edges
+}
+
+func resizePanel(r image.Rectangle, edges int, delta image.Point, minSize Size) image.Rectangle {
+	if edges&edgeLeft != 0 {
+		r.Min.X = Min(r.Min.X+delta.X, r.Max.X-minSize.X)
+	}
+	if edges&edgeRight != 0 {
+		r.Max.X = Max(r.Max.X+delta.X, r.Min.X+minSize.X)
+	}
+	if edges&edgeBottom != 0 {
+		r.Max.Y = Max(r.Max.Y+delta.Y, r.Min.Y+minSize.Y)
+	}
+	return r
+}
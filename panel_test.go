@@ -0,0 +1,77 @@
+package tomato
+
+import (
+	"image"
+	"testing"
+)
+
+// moving, resizing, collapsing and closing a panel with the mouse, the button behind it gets nothing
+func TestPanelMouse(t *testing.T) {
+	testWindow(t, 400, 400)
+	open, content, behind := true, false, false
+	frame := func() {
+		Layout(0, Vertical, image.Rect(0, 0, 400, 400))
+		StyleLayout(LayoutStyle{Padding: 0})
+		behind = TextButton(0, "behind", nil) || behind
+		content = BeginPanel(1, "panel", image.Rect(20, 0, 220, 200), &open)
+		EndPanel()
+		DrawUi()
+	}
+	drag := func(from, to image.Point) {
+		moveTo(from.X, from.Y)
+		frame()
+		press(MouseLeft)
+		frame()
+		moveTo(to.X, to.Y)
+		frame()
+		release(MouseLeft)
+		frame()
+	}
+	frame()
+	p := ui_frame.panels[1]
+	titleH := ui_frame.DefaultTheme.FontFace.Metrics().Height.Ceil() + 2*Y_MARGIN
+
+	// the panel is over the button
+	button := image.Pt(100, 5)
+	drag(button, button)
+	if !WantsMouse() || behind {
+		t.Errorf("the click on the panel went to the button behind it")
+	}
+
+	drag(image.Pt(120, titleH/2), image.Pt(150, 100+titleH/2))
+	if p.Rect != image.Rect(50, 100, 250, 300) {
+		t.Errorf("the panel dragged by its title bar is at %v", p.Rect)
+	}
+	drag(image.Pt(248, 200), image.Pt(298, 250))
+	if p.Rect != image.Rect(50, 100, 300, 300) {
+		t.Errorf("the panel resized by its right edge is at %v", p.Rect)
+	}
+	drag(image.Pt(150, 298), image.Pt(150, 100))
+	if p.Rect.Dy() != 2*titleH {
+		t.Errorf("the panel was resized below its minimum to %v", p.Rect)
+	}
+	if behind {
+		t.Errorf("the button behind the panel was clicked")
+	}
+
+	collapse := image.Pt(p.Rect.Min.X+titleH/2, p.Rect.Min.Y+titleH/2)
+	drag(collapse, collapse)
+	if !p.Collapsed || content {
+		t.Errorf("the collapse button didn't collapse the panel")
+	}
+	drag(collapse, collapse)
+	if p.Collapsed || !content {
+		t.Errorf("the collapse button didn't open the panel again")
+	}
+
+	closeP := image.Pt(p.Rect.Max.X-titleH/2, p.Rect.Min.Y+titleH/2)
+	drag(closeP, closeP)
+	if open || content {
+		t.Errorf("the close button didn't close the panel")
+	}
+	// the button isn't behind anything now
+	drag(button, button)
+	if !behind {
+		t.Errorf("the button behind the closed panel wasn't clicked")
+	}
+}
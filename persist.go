@@ -0,0 +1,190 @@
+/*
+   Saving the state of the Ui, so tools open again the way they were left:
+
+       tomato.SetupUi()
+       tomato.PersistUi("ui.json") // loads it now, saves it when the window is destroyed
+
+   What is saved, by id:
+       layouts     where they are (Layout() only uses its place the first time) and the scroll of scroll panels
+       panels      place, collapsed and open
+       widgets     the values of checkboxes, radios, sliders, drag numbers and text fields
+
+   The saved values are given back when the layout, panel or widget shows up
+   for the first time, the values in the program are overwritten then.
+   A widget that isn't made in a frame lets go of its value, its last value
+   is saved.
+   SaveUi/LoadUi do the same with any io.Writer/io.Reader.
+*/
+
+package tomato
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"os"
+)
+
+type uiState struct {
+	Layouts map[int]layoutState    `json:"layouts"`
+	Panels  map[int]panelState     `json:"panels"`
+	Values  map[ID]json.RawMessage `json:"values"`
+}
+
+type layoutState struct {
+	Place  image.Rectangle
+	Scroll int
+}
+
+type panelState struct {
+	Rect      image.Rectangle
+	Collapsed bool
+	Open      bool
+}
+
+// Loads the Ui state from path if it is there, and saves it there when the window is destroyed
+func PersistUi(path string) error {
+	ui_frame.persistPath = path
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return LoadUi(f)
+}
+
+// Writes the state of the Ui of the current window as json
+func SaveUi(w io.Writer) error {
+	return ui_frame.save(w)
+}
+
+// Reads a state written by SaveUi, call it after SetupUi()
+func LoadUi(r io.Reader) error {
+	var state uiState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return err
+	}
+	ui_frame.restored = state
+
+	// the ones that are already there
+	for id := range ui_frame.Layouts {
+		ui_frame.restoreLayout(id)
+	}
+	for id, p := range ui_frame.panels {
+		ui_frame.restorePanel(id, p)
+	}
+	return nil
+}
+
+func (frame *Ui_Frame) save(w io.Writer) error {
+	state := uiState{
+		Layouts: make(map[int]layoutState, len(frame.Layouts)),
+		Panels:  make(map[int]panelState, len(frame.panels)),
+		Values:  make(map[ID]json.RawMessage, len(frame.values)),
+	}
+	for id, lay := range frame.Layouts {
+		state.Layouts[id] = layoutState{Place: lay.Place, Scroll: lay.Scroll}
+	}
+	for id, p := range frame.panels {
+		state.Panels[id] = panelState{Rect: p.Rect, Collapsed: p.Collapsed, Open: p.open}
+	}
+	// the ones we didn't see yet stay, and the ones that went away
+	for id, raw := range frame.restored.Values {
+		state.Values[id] = raw
+	}
+	for id, raw := range frame.gone {
+		state.Values[id] = raw
+	}
+	for id, v := range frame.values {
+		raw, err := json.Marshal(v.value)
+		if err != nil {
+			return err
+		}
+		state.Values[id] = raw
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(state)
+}
+
+// called when the window is destroyed
+func (frame *Ui_Frame) saveFile() {
+	if frame.persistPath == "" {
+		return
+	}
+	f, err := os.Create(frame.persistPath)
+	if err == nil {
+		err = frame.save(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Println("\ntomato ERROR: saving the Ui state:", err)
+	}
+}
+
+func (frame *Ui_Frame) restoreLayout(id int) {
+	s, ok := frame.restored.Layouts[id]
+	if !ok {
+		return
+	}
+	delete(frame.restored.Layouts, id)
+	lay := &frame.Layouts[id]
+	lay.Place = s.Place
+	lay.Scroll = s.Scroll
+	lay.restart()
+}
+
+func (frame *Ui_Frame) restorePanel(id int, p *panel) {
+	s, ok := frame.restored.Panels[id]
+	if !ok {
+		return
+	}
+	delete(frame.restored.Panels, id)
+	p.Rect = s.Rect
+	p.Collapsed = s.Collapsed
+	p.open = s.Open
+	p.restoreOpen = true
+}
+
+// Gives the widget id its saved value the first time, and remembers where the value is for saving
+func persistValue[T any](id ID, value *T) {
+	frame := ui_frame
+	if raw, ok := frame.restored.Values[id]; ok {
+		delete(frame.restored.Values, id)
+		var v T
+		if json.Unmarshal(raw, &v) == nil {
+			*value = v
+		}
+	}
+	frame.values[id] = persistedValue{value: value, used: true}
+	delete(frame.gone, id)
+}
+
+type persistedValue struct {
+	value any  // a pointer to it
+	used  bool // in this frame
+}
+
+// Forgets the values of the widgets that weren't there this frame, like the
+// layouts forget their elements. Their last value is kept for saving.
+func (frame *Ui_Frame) endValues() {
+	for id, v := range frame.values {
+		if v.used {
+			frame.values[id] = persistedValue{value: v.value}
+			continue
+		}
+		if raw, err := json.Marshal(v.value); err == nil {
+			frame.gone[id] = raw
+		}
+		delete(frame.values, id)
+	}
+}
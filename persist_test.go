@@ -0,0 +1,60 @@
+package tomato
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"testing"
+)
+
+// the values of widgets that went away are let go, but still saved
+func TestPersistEvictsValues(t *testing.T) {
+	testWindow(t, 200, 200)
+
+	checked := true
+	frame := func(show bool) {
+		Layout(0, Vertical, image.Rect(0, 0, 200, 200))
+		if show {
+			Checkbox(0, "check", &checked, nil)
+		}
+		DrawUi()
+	}
+	saved := func() map[ID]json.RawMessage {
+		var buf bytes.Buffer
+		if err := SaveUi(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var state uiState
+		if err := json.Unmarshal(buf.Bytes(), &state); err != nil {
+			t.Fatal(err)
+		}
+		return state.Values
+	}
+
+	frame(true)
+	id := intID(0) // the layout 0 is still the active one
+	if _, ok := ui_frame.values[id]; !ok {
+		t.Fatalf("the checkbox value isn't there")
+	}
+
+	frame(false)
+	if len(ui_frame.values) != 0 {
+		t.Errorf("the value of the checkbox that went away is still there")
+	}
+	if got := string(saved()[id]); got != "true" {
+		t.Errorf("the value of the checkbox that went away is saved as %q, want true", got)
+	}
+
+	// back again, with the value of the program
+	checked = false
+	frame(true)
+	if checked {
+		t.Errorf("the value that went away was given back")
+	}
+	if got := string(saved()[id]); got != "false" {
+		t.Errorf("the checkbox is saved as %q, want false", got)
+	}
+	if len(ui_frame.gone) != 0 {
+		t.Errorf("the checkbox is still gone")
+	}
+}
@@ -0,0 +1,135 @@
+/*
+   Recording and replaying of the event stream.
+
+   Every event that goes through Inject() (so also the ones from the window)
+   can be written to a file together with the frame it happened in.
+   Replay() feeds such a recording back frame by frame, so a session can be
+   reproduced exactly, as long as the program draws the same frames.
+
+   The file has one json object per line:
+       {"Frame":12,"Time":200105000,"Ev":{"Kind":2,"X":100,"Y":34,...}}
+   Frame counts the calls to Alive() since the recording started,
+   Time is the time since the start in nanoseconds (informational only).
+*/
+
+package tomato
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type RecordedEv struct {
+	Frame uint64
+	Time  time.Duration
+	Ev    Ev
+}
+
+// recording and replay state of a window
+type recording struct {
+	lock       sync.Mutex
+	enc        *json.Encoder
+	err        error
+	startFrame uint64
+	startTime  time.Time
+
+	replayQueue      []RecordedEv
+	replayStartFrame uint64
+}
+
+// Number of calls to Alive() so far
+func FrameCount() uint64 {
+	return current.FrameCount()
+}
+
+func (w *Window) FrameCount() uint64 {
+	return w.frame
+}
+
+// Starts writing all events to w, until StopRecording() is called.
+func StartRecording(w io.Writer) {
+	current.StartRecording(w)
+}
+
+func (w *Window) StartRecording(out io.Writer) {
+	w.rec.lock.Lock()
+	w.rec.enc = json.NewEncoder(out)
+	w.rec.err = nil
+	w.rec.startFrame = w.frame
+	w.rec.startTime = time.Now()
+	w.rec.lock.Unlock()
+}
+
+// Returns the first error that happened while writing, if any.
+func StopRecording() error {
+	return current.StopRecording()
+}
+
+func (w *Window) StopRecording() error {
+	w.rec.lock.Lock()
+	defer w.rec.lock.Unlock()
+	w.rec.enc = nil
+	return w.rec.err
+}
+
+func (w *Window) record(ev Ev) {
+	w.rec.lock.Lock()
+	defer w.rec.lock.Unlock()
+	if w.rec.enc == nil || w.rec.err != nil {
+		return
+	}
+	w.rec.err = w.rec.enc.Encode(RecordedEv{
+		Frame: w.frame - w.rec.startFrame,
+		Time:  time.Since(w.rec.startTime),
+		Ev:    ev,
+	})
+}
+
+// Reads a recording from r and injects its events in the following frames,
+// each in the same frame (relative to the start) it was recorded in.
+// A replay that is still running is replaced.
+func Replay(r io.Reader) error {
+	return current.Replay(r)
+}
+
+func (w *Window) Replay(r io.Reader) error {
+	var evs []RecordedEv
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var rev RecordedEv
+		err := dec.Decode(&rev)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		evs = append(evs, rev)
+	}
+
+	w.rec.replayQueue = evs
+	// the frame we are in is half done, the events of frame 0 go into the next one
+	w.rec.replayStartFrame = w.frame + 1
+	return nil
+}
+
+// true while there are recorded events left to inject
+func Replaying() bool {
+	return current.Replaying()
+}
+
+func (w *Window) Replaying() bool {
+	return len(w.rec.replayQueue) > 0
+}
+
+// called once per frame from Alive()
+func (w *Window) replay() {
+	for len(w.rec.replayQueue) > 0 && w.rec.replayStartFrame+w.rec.replayQueue[0].Frame <= w.frame {
+		ev := w.rec.replayQueue[0].Ev
+		w.rec.replayQueue = w.rec.replayQueue[1:]
+		w.Inject(ev)
+	}
+}
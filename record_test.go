@@ -0,0 +1,42 @@
+package tomato
+
+import (
+	"bytes"
+	"testing"
+)
+
+// the replayed events come in the frames they were recorded in
+func TestRecordReplay(t *testing.T) {
+	w := testWindow(t, 100, 100)
+
+	var buf bytes.Buffer
+	w.Alive()
+	StartRecording(&buf)
+	for x := 1; x <= 3; x++ {
+		if x > 1 {
+			w.Alive()
+		}
+		moveTo(x, 0)
+	}
+	if err := StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+
+	moveTo(0, 0)
+	w.Alive()
+	if err := Replay(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if w.MouseX != 0 {
+		t.Fatalf("the replay started in the frame Replay() was called in")
+	}
+	for x := 1; x <= 3; x++ {
+		w.Alive()
+		if w.MouseX != x {
+			t.Errorf("in frame %v of the replay the mouse is at %v, want %v", x-1, w.MouseX, x)
+		}
+	}
+	if Replaying() {
+		t.Errorf("the replay didn't end with the recording")
+	}
+}
@@ -0,0 +1,258 @@
+/*
+   Rich text, spans of text with their own look, laid out (and wrapped)
+   together like one text:
+
+       spans := []tomato.Span{
+           {Text: "Build "},
+           {Text: "failed", Color: red, Bold: true},
+           {Text: ": 3 errors"},
+       }
+       img := tomato.RenderRichText(spans, textColor, bgColor, face, tomato.TextOptions{MaxWidth: 400})
+
+   The spans without Color get the text color, the ones without a font the
+   face. Size, Bold and Italic pick a font by name, FontName of the span or
+   the one the face was made with by Font: Bold is "<name>-bold", Italic
+   "<name>-italic" and both "<name>-bold-italic", if there is no such font
+   the closest one is used. The size is the one of the face if the span has
+   none. For faces that don't come from Font the FontName and FontSize of
+   the TextOptions are used.
+
+   ParseMarkup makes spans out of a BBCode like markup:
+
+       spans, err := tomato.ParseMarkup("Build [color=red][b]failed[/b][/color]: 3 errors")
+
+       [b] [i] [u] [s]      bold, italic, underline, strikethrough
+       [color=#ff0000]      or a name: red, green, blue, yellow, orange, white, black, gray
+       [bg=yellow]          background highlight
+       [size=32] [font=mono]
+       [[                   a [
+
+   Every tag is closed with [/name], in the reverse order they were opened.
+*/
+
+package tomato
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+type Span struct {
+	Text  string
+	Color color.RGBA // zero is the text color
+
+	Face     font.Face // wins over the rest
+	FontName string
+	Size     float64 // in points
+	Bold     bool
+	Italic   bool
+
+	Underline  bool
+	Strike     bool
+	Background color.RGBA // highlight, zero is none
+}
+
+// Breaks the spans into lines, like LayoutText
+func LayoutRichText(spans []Span, face font.Face, opts TextOptions) *TextLayout {
+	placed := make([]textSpan, 0, len(spans))
+	start := 0
+	for _, s := range spans {
+		if s.Text == "" {
+			continue
+		}
+		placed = append(placed, textSpan{
+			Span:  s,
+			start: start,
+			end:   start + len(s.Text),
+			face:  s.pickFace(face, opts),
+		})
+		start += len(s.Text)
+	}
+	return layoutSpans(placed, face, opts)
+}
+
+func MeasureRichText(spans []Span, face font.Face, opts TextOptions) Size {
+	return LayoutRichText(spans, face, opts).Size
+}
+
+// Like RenderTextOptions with spans
+func RenderRichText(spans []Span, textColor, bgColor color.RGBA, face font.Face, opts TextOptions) draw.Image {
+	return LayoutRichText(spans, face, opts).render(textColor, bgColor)
+}
+
+func (s *Span) pickFace(face font.Face, opts TextOptions) font.Face {
+	if s.Face != nil {
+		return s.Face
+	}
+	if s.FontName == "" && s.Size == 0 && !s.Bold && !s.Italic {
+		return face
+	}
+	// like the face, if we know where it comes from
+	base, ok := faceKeyOf(face)
+	if !ok {
+		base = faceKey{opts.FontName, opts.FontSize, DPI}
+		if base.name == "" {
+			base.name = "mono"
+		}
+		if base.size == 0 {
+			base.size = TEXT_SIZE
+		}
+	}
+	name := s.FontName
+	if name == "" {
+		name = base.name
+	}
+	size := s.Size
+	if size == 0 {
+		size = base.size
+	}
+
+	// the closest one there is
+	names := []string{name}
+	switch {
+	case s.Bold && s.Italic:
+		names = []string{name + "-bold-italic", name + "-bold", name + "-italic", name}
+	case s.Bold:
+		names = []string{name + "-bold", name}
+	case s.Italic:
+		names = []string{name + "-italic", name}
+	}
+	for _, n := range names {
+		if f, err := makeFace(n, size, base.dpi); err == nil {
+			return f
+		}
+	}
+	return face
+}
+
+// underline and strikethrough from x0 to x1
+func (s *textSpan) strokes(dst draw.Image, x0, x1 fixed.Int26_6, baseline int, c color.RGBA) {
+	if !s.Underline && !s.Strike {
+		return
+	}
+	m := s.face.Metrics()
+	thick := Max(m.Height.Round()/16, 1)
+	line := func(y int) {
+		r := image.Rect(x0.Floor(), y, x1.Ceil(), y+thick)
+		draw.Draw(dst, r, image.NewUniform(c), image.ZP, draw.Over)
+	}
+	if s.Underline {
+		line(baseline + Max(m.Descent.Round()/3, 1))
+	}
+	if s.Strike {
+		line(baseline - m.Ascent.Round()*3/10)
+	}
+}
+
+var markupColors = map[string]color.RGBA{
+	"red":    {220, 50, 47, 255},
+	"green":  {70, 170, 60, 255},
+	"blue":   {60, 110, 220, 255},
+	"yellow": {240, 200, 40, 255},
+	"orange": {240, 130, 30, 255},
+	"white":  {255, 255, 255, 255},
+	"black":  {0, 0, 0, 255},
+	"gray":   {128, 128, 128, 255},
+}
+
+// Makes spans out of markup, see the rich text
+func ParseMarkup(markup string) ([]Span, error) {
+	var spans []Span
+	var text strings.Builder
+	var open []string // the tags, innermost last
+	stack := []Span{{}}
+
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		s := stack[len(stack)-1]
+		s.Text = text.String()
+		spans = append(spans, s)
+		text.Reset()
+	}
+
+	for i := 0; i < len(markup); {
+		if markup[i] != '[' {
+			j := strings.IndexByte(markup[i:], '[')
+			if j < 0 {
+				j = len(markup) - i
+			}
+			text.WriteString(markup[i : i+j])
+			i += j
+			continue
+		}
+		if strings.HasPrefix(markup[i:], "[[") {
+			text.WriteByte('[')
+			i += 2
+			continue
+		}
+		end := strings.IndexByte(markup[i:], ']')
+		if end < 0 {
+			return nil, fmt.Errorf("markup: [ at %d is not closed", i)
+		}
+		tag := markup[i+1 : i+end]
+		at := i
+		i += end + 1
+		flush()
+
+		if name, ok := strings.CutPrefix(tag, "/"); ok {
+			if len(open) == 0 || open[len(open)-1] != name {
+				return nil, fmt.Errorf("markup: [/%s] at %d doesn't close the last open tag", name, at)
+			}
+			open = open[:len(open)-1]
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		name, value, _ := strings.Cut(tag, "=")
+		s := stack[len(stack)-1]
+		switch name {
+		case "b":
+			s.Bold = true
+		case "i":
+			s.Italic = true
+		case "u":
+			s.Underline = true
+		case "s":
+			s.Strike = true
+		case "color", "bg":
+			c, ok := markupColors[strings.ToLower(value)]
+			if !ok {
+				var err error
+				if c, err = parseColor(value); err != nil {
+					return nil, fmt.Errorf("markup: [%s] at %d: %w", tag, at, err)
+				}
+			}
+			if name == "color" {
+				s.Color = c
+			} else {
+				s.Background = c
+			}
+		case "size":
+			size, err := strconv.ParseFloat(value, 64)
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("markup: [%s] at %d: bad size", tag, at)
+			}
+			s.Size = size
+		case "font":
+			s.FontName = value
+		default:
+			return nil, fmt.Errorf("markup: unknown tag [%s] at %d", tag, at)
+		}
+		open = append(open, name)
+		stack = append(stack, s)
+	}
+	flush()
+	if len(open) > 0 {
+		return nil, fmt.Errorf("markup: [%s] is not closed", open[len(open)-1])
+	}
+	return spans, nil
+}
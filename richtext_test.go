@@ -0,0 +1,79 @@
+package tomato
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/font"
+)
+
+func TestParseMarkup(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	tests := []struct {
+		markup string
+		want   []Span
+		err    bool
+	}{
+		{markup: "", want: nil},
+		{markup: "plain", want: []Span{{Text: "plain"}}},
+		{markup: "a [b]b[/b] c", want: []Span{{Text: "a "}, {Text: "b", Bold: true}, {Text: " c"}}},
+		{markup: "[b][i]x[/i]y[/b]", want: []Span{{Text: "x", Bold: true, Italic: true}, {Text: "y", Bold: true}}},
+		{markup: "[color=Red]r[/color]", want: []Span{{Text: "r", Color: markupColors["red"]}}},
+		{markup: "[color=#ff0000]r[/color]", want: []Span{{Text: "r", Color: red}}},
+		{markup: "[bg=red][u][s]r[/s][/u][/bg]", want: []Span{{Text: "r", Background: markupColors["red"], Underline: true, Strike: true}}},
+		{markup: "[size=32][font=sans]big[/font][/size]", want: []Span{{Text: "big", Size: 32, FontName: "sans"}}},
+		{markup: "[[b]", want: []Span{{Text: "[b]"}}},
+		{markup: "[b]x", err: true},
+		{markup: "[b]x[/i]", err: true},
+		{markup: "[b][i]x[/b][/i]", err: true},
+		{markup: "x[/b]", err: true},
+		{markup: "[b", err: true},
+		{markup: "[blink]x[/blink]", err: true},
+		{markup: "[size=0]x[/size]", err: true},
+		{markup: "[color=nope]x[/color]", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMarkup(tt.markup)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseMarkup(%q) error %v, want error %v", tt.markup, err, tt.err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMarkup(%q) = %+v, want %+v", tt.markup, got, tt.want)
+		}
+	}
+}
+
+// a bold span without a font keeps the font and size of the face
+func TestRichTextSpanFace(t *testing.T) {
+	keyOf := func(face font.Face) faceKey {
+		key, _ := faceKeyOf(face)
+		return key
+	}
+	base := Font("sans", 16)
+	spans, err := ParseMarkup("status [b]error[/b] ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := LayoutRichText(spans, base, TextOptions{})
+	if got, want := l.spans[1].face, Font("sans-bold", 16); got != want {
+		t.Errorf("the bold span has %v, want sans-bold 16", keyOf(got))
+	}
+	plain := LayoutText("status error ok", base, TextOptions{})
+	if l.Size.Y != plain.Size.Y {
+		t.Errorf("the line with the bold span is %v high, without it %v", l.Size.Y, plain.Size.Y)
+	}
+
+	// Size wins, the name still comes from the face
+	l = LayoutRichText([]Span{{Text: "big", Size: 24, Italic: true}}, base, TextOptions{})
+	if got, want := l.spans[0].face, Font("sans-italic", 24); got != want {
+		t.Errorf("the span has %v, want sans-italic 24", keyOf(got))
+	}
+
+	// a face that doesn't come from Font uses the options
+	l = LayoutRichText([]Span{{Text: "b", Bold: true}}, &fallbackFace{faces: []font.Face{base}, which: make(map[rune]int)}, TextOptions{FontName: "mono", FontSize: 12})
+	if got, want := l.spans[0].face, Font("mono-bold", 12); got != want {
+		t.Errorf("the span has %v, want mono-bold 12", keyOf(got))
+	}
+}
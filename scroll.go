@@ -0,0 +1,130 @@
+/*
+   Scroll panels, a vertical layout in the next cell of the active layout
+   that shows only what fits into the cell:
+
+       tomato.Layout(0, tomato.Vertical, image.Rect(0, 0, 400, 600))
+       tomato.ScrollPanel(1, tomato.Size{0, 300})
+       for i, file := range files {
+           tomato.TextButton(i, file, nil)
+       }
+       tomato.EndScrollPanel()
+
+   The ids come from the same range as the ones of the layouts. The mouse
+   wheel scrolls the panel under the mouse (the innermost one), so do
+   PageUp/PageDown. With more content than space there is a scrollbar on
+   the right, its thumb can be dragged and a click next to it pages.
+*/
+
+package tomato
+
+import (
+	"image"
+	"math"
+	"strconv"
+)
+
+const SCROLL_STEP int = 40 // pixels per notch of the mouse wheel
+
+// Starts a scroll panel in the next cell of the active layout, it is active until EndScrollPanel().
+// A zero size.X fills the width, a zero size.Y the rest of the height of the active layout.
+func ScrollPanel(id int, size Size) {
+	if len(ui_frame.stack) == 0 {
+		panic("\ntomato ERROR: call Layout(...) before ScrollPanel(...)\n")
+	}
+	parent := &ui_frame.Layouts[ui_frame.Active]
+	if size.Y == 0 && parent.Ori != Horizontal {
+		size.Y = Max(parent.inner().Max.Y-parent.NextPos.Y, 0)
+	}
+	view := parent.next(size)
+
+	if id >= len(ui_frame.Layouts) {
+		newLayout(id, Vertical, view)
+	}
+	lay := &ui_frame.Layouts[id]
+	lay.Ori = Vertical
+	lay.view = view
+
+	// the content of the last frame decides how far we can go
+	content := lay.lastExtent + 2*lay.Style.Padding
+	lay.Scroll = Max(Min(lay.Scroll, content-view.Dy()), 0)
+
+	place := view
+	if content > view.Dy() {
+		place.Max.X -= ui_frame.style().ScrollbarWidth
+		scrollbar(id, lay, content)
+	}
+	place = place.Sub(image.Pt(0, lay.Scroll))
+	if lay.Place != place {
+		lay.Place = place
+		lay.restart()
+	}
+
+	current.PushClip(view)
+	ui_frame.Active = id
+	ui_frame.stack = append(ui_frame.stack, id)
+}
+
+// Goes back to the layout that was active before ScrollPanel()
+func EndScrollPanel() {
+	lay := &ui_frame.Layouts[ui_frame.Active]
+	current.PopClip()
+	EndLayout()
+
+	// here and not in ScrollPanel(), so the innermost panel gets the wheel
+	// it only moves in the next frame, like the alignment
+	if !mouseIn(lay.view) {
+		return
+	}
+	page := lay.view.Dy() * 9 / 10
+	lay.Scroll -= ui_frame.takeWheel().Y * SCROLL_STEP
+	for ui_frame.takeKeyRepeating(PageUp) {
+		lay.Scroll -= page
+	}
+	for ui_frame.takeKeyRepeating(PageDown) {
+		lay.Scroll += page
+	}
+}
+
+// the scrollbar of the panel lay, content is the height of what is in it
+func scrollbar(id int, lay *layout, content int) {
+	view := lay.view
+	st := ui_frame.style()
+	track := image.Rect(view.Max.X-st.ScrollbarWidth, view.Min.Y, view.Max.X, view.Max.Y)
+	maxScroll := content - view.Dy()
+
+	thumbH := Max(view.Dy()*view.Dy()/content, st.ScrollbarWidth)
+	thumbY := int(math.Round(float64(lay.Scroll) / float64(maxScroll) * float64(view.Dy()-thumbH)))
+	thumb := image.Rect(track.Min.X, track.Min.Y+thumbY, track.Max.X, track.Min.Y+thumbY+thumbH)
+
+	barID := hashID(idSeed, "#scrollbar#"+strconv.Itoa(id))
+	mouseY := current.MouseY
+
+	if clickedIn(track) {
+		ui_frame.dragStart = image.Pt(current.MouseX, mouseY)
+		ui_frame.dragValue = float64(lay.Scroll)
+		if !mouseIn(thumb) {
+			// page towards the click
+			if mouseY < thumb.Min.Y {
+				lay.Scroll -= view.Dy()
+			} else {
+				lay.Scroll += view.Dy()
+			}
+		}
+	}
+	if ui_frame.drag(barID, thumb) && view.Dy() > thumbH {
+		moved := float64(mouseY-ui_frame.dragStart.Y) * float64(maxScroll) / float64(view.Dy()-thumbH)
+		lay.Scroll = int(math.Round(ui_frame.dragValue + moved))
+	}
+	lay.Scroll = Max(Min(lay.Scroll, maxScroll), 0)
+
+	// again, with where we are now
+	thumbY = int(math.Round(float64(lay.Scroll) / float64(maxScroll) * float64(view.Dy()-thumbH)))
+	thumb = image.Rect(track.Min.X, track.Min.Y+thumbY, track.Max.X, track.Min.Y+thumbY+thumbH)
+
+	ToDraw(track, image.NewUniform(st.ScrollbarTrack))
+	thumbColor := st.ScrollbarThumb
+	if ui_frame.dragging == barID || mouseIn(thumb) {
+		thumbColor = st.ScrollbarThumbActive
+	}
+	ToDraw(thumb, image.NewUniform(thumbColor))
+}
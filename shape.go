@@ -0,0 +1,430 @@
+/*
+   Shaping: Arabic, Hebrew, the Indic scripts and the other ones where
+   glyphs aren't simply put one after the other. Text with runes of such
+   scripts goes through the Unicode bidi algorithm, so right to left runs
+   are reordered (also mixed with left to right ones, "שלום world"), and a
+   HarfBuzz shaper (github.com/go-text/typesetting) that picks the joined
+   forms, ligatures and positions of the marks out of the font. All other
+   text is placed rune by rune with kerning, like font.Drawer does.
+
+   It works with the faces of Font and LoadFont, other faces only get the
+   reordering. The font needs the glyphs of course, the Go fonts don't have
+   Arabic or Devanagari ones:
+
+       tomato.LoadFont("arabic", "fonts/NotoSansArabic-Regular.ttf")
+       tomato.LoadFont("devanagari", "fonts/NotoSansDevanagari-Regular.ttf")
+       tomato.FontFallback("mono", "arabic", "devanagari")
+
+   Everything that draws or measures text shapes it: DrawText, RenderText,
+   the text layout, the widgets and the text input. The caret of the text
+   input moves through the text in logical order and is drawn where the rune
+   is on the screen. In the text layout the first strong rune of a paragraph
+   decides its direction, for all of its lines.
+*/
+
+package tomato
+
+import (
+	"image"
+	"image/draw"
+	"slices"
+	"sync"
+	"unicode"
+
+	"github.com/go-text/typesetting/bidi"
+	"github.com/go-text/typesetting/di"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+	xbidi "golang.org/x/text/unicode/bidi"
+)
+
+// the scripts that need shaping, the first ones are the right to left ones
+var complexScripts = []*unicode.RangeTable{
+	unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko, unicode.Samaritan, unicode.Mandaic,
+	unicode.Devanagari, unicode.Bengali, unicode.Gurmukhi, unicode.Gujarati, unicode.Oriya, unicode.Tamil,
+	unicode.Telugu, unicode.Kannada, unicode.Malayalam, unicode.Sinhala,
+	unicode.Thai, unicode.Lao, unicode.Tibetan, unicode.Myanmar, unicode.Khmer,
+}
+
+const rtlScripts = 7 // in complexScripts
+
+// a glyph of shaped text
+type shapedGlyph struct {
+	face    font.Face // the one of the fallbacks that has it
+	r       rune      // -1 if it is drawn by index
+	index   sfnt.GlyphIndex
+	x       fixed.Int26_6   // of the dot, from the start of the text
+	offset  fixed.Point26_6 // from the dot
+	advance fixed.Int26_6
+
+	start, end int // the bytes of the text the glyph is for, its cluster
+	rtl        bool
+}
+
+type shapedText struct {
+	glyphs  []shapedGlyph // left to right
+	advance fixed.Int26_6
+}
+
+type shapeKey struct {
+	text string
+	face font.Face
+	dir  bidi.Direction
+}
+
+var (
+	shapeLock sync.Mutex
+	shaper    shaping.HarfbuzzShaper
+	paragraph bidi.Paragraph
+	// @Memory cleared when it gets too big
+	shapeCache = make(map[shapeKey]*shapedText)
+)
+
+// true if text has runes of complexScripts or bidi controls
+func needsShaping(text string) bool {
+	for _, r := range text {
+		if r < 0x0590 {
+			continue
+		}
+		if unicode.In(r, complexScripts...) || r == 0x200E || r == 0x200F || r >= 0x202A && r <= 0x202E || r >= 0x2066 && r <= 0x2069 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRTL(text string) bool {
+	for _, r := range text {
+		if r >= 0x0590 && unicode.In(r, complexScripts[:rtlScripts]...) {
+			return true
+		}
+	}
+	return false
+}
+
+// The direction of the paragraph text, from its first strong rune (rules P2
+// and P3 of the bidi algorithm). Left to right if it has none.
+func paragraphDir(text string) bidi.Direction {
+	isolates := 0
+	for _, r := range text {
+		p, _ := xbidi.LookupRune(r)
+		switch p.Class() {
+		case xbidi.LRI, xbidi.RLI, xbidi.FSI:
+			isolates++
+		case xbidi.PDI:
+			isolates = max(isolates-1, 0)
+		case xbidi.L:
+			if isolates == 0 {
+				return bidi.LeftToRight
+			}
+		case xbidi.R, xbidi.AL:
+			if isolates == 0 {
+				return bidi.RightToLeft
+			}
+		}
+	}
+	return bidi.LeftToRight
+}
+
+// The glyphs of text in the order they are on the screen, nil if it
+// doesn't need shaping. The results are cached.
+// The direction of the paragraph comes from the text itself.
+func shape(text string, face font.Face) *shapedText {
+	return shapeDir(text, face, bidi.Neutral)
+}
+
+// Like shape, text is a part of a paragraph going in the direction dir.
+// Every line of a right to left paragraph is shaped, its neutral runes go
+// right to left too.
+func shapeDir(text string, face font.Face, dir bidi.Direction) *shapedText {
+	if dir != bidi.RightToLeft && !needsShaping(text) {
+		return nil
+	}
+	shapeLock.Lock()
+	defer shapeLock.Unlock()
+	key := shapeKey{text, face, dir}
+	if s, ok := shapeCache[key]; ok {
+		return s
+	}
+
+	var runes []rune
+	var offsets []int // of every rune in text, and the end
+	for i, r := range text {
+		runes = append(runes, r)
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(text))
+
+	// runs with the same direction, script and face
+	type segment struct {
+		start, end int // in runes
+		level      bidi.Level
+		script     language.Script
+		face       font.Face
+	}
+	var segments []segment
+	runs := paragraph.Segment(runes, dir)
+	for i := 0; i < runs.NumRuns(); i++ {
+		run := runs.Run(i)
+		for a := run.Start; a < run.End; {
+			seg := segment{start: a, level: run.Level, script: language.LookupScript(runes[a]), face: faceFor(face, runes[a])}
+			b := a + 1
+			for ; b < run.End; b++ {
+				r := runes[b]
+				script := language.LookupScript(r)
+				if script != language.Common && script != language.Inherited {
+					if seg.script == language.Common || seg.script == language.Inherited {
+						seg.script = script
+					} else if script != seg.script {
+						break
+					}
+				}
+				// the marks go with the rune before them
+				if !isMark(r) && faceFor(face, r) != seg.face {
+					break
+				}
+			}
+			seg.end = b
+			segments = append(segments, seg)
+			a = b
+		}
+	}
+
+	// the visual order: from the highest level to the lowest odd one,
+	// the segments at that level or higher are reversed
+	highest, lowestOdd := bidi.Level(0), bidi.Level(127)
+	for _, seg := range segments {
+		highest = max(highest, seg.level)
+		if seg.level%2 == 1 {
+			lowestOdd = min(lowestOdd, seg.level)
+		}
+	}
+	for level := highest; level >= lowestOdd; level-- {
+		for i := 0; i < len(segments); {
+			if segments[i].level < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(segments) && segments[j].level >= level {
+				j++
+			}
+			slices.Reverse(segments[i:j])
+			i = j
+		}
+	}
+
+	s := &shapedText{}
+	for _, seg := range segments {
+		rtl := seg.level%2 == 1
+		src, ok := faceSourceOf(seg.face)
+		if !ok {
+			// not one of ours, only reordered
+			// @Todo mirror the brackets in right to left runs
+			for k := seg.start; k < seg.end; k++ {
+				j := k
+				if rtl {
+					j = seg.end - 1 - (k - seg.start)
+				}
+				adv, _ := seg.face.GlyphAdvance(runes[j])
+				s.glyphs = append(s.glyphs, shapedGlyph{
+					face: seg.face, r: runes[j], x: s.advance, advance: adv,
+					start: offsets[j], end: offsets[j+1], rtl: rtl,
+				})
+				s.advance += adv
+			}
+			continue
+		}
+
+		dir := di.DirectionLTR
+		if rtl {
+			dir = di.DirectionRTL
+		}
+		out := shaper.Shape(shaping.Input{
+			Text:      runes,
+			RunStart:  seg.start,
+			RunEnd:    seg.end,
+			Direction: dir,
+			Face:      src.font.shaping,
+			Size:      src.scale,
+			Script:    seg.script,
+		})
+		// harfbuzz gives them left to right already
+		for _, g := range out.Glyphs {
+			s.glyphs = append(s.glyphs, shapedGlyph{
+				face:    seg.face,
+				r:       -1,
+				index:   sfnt.GlyphIndex(g.GlyphID),
+				x:       s.advance,
+				offset:  fixed.Point26_6{X: g.XOffset, Y: -g.YOffset},
+				advance: g.XAdvance,
+				start:   offsets[g.ClusterIndex],
+				end:     offsets[min(g.ClusterIndex+g.RuneCount, len(runes))],
+				rtl:     rtl,
+			})
+			s.advance += g.XAdvance
+		}
+	}
+
+	if len(shapeCache) > 4096 {
+		clear(shapeCache)
+	}
+	shapeCache[key] = s
+	return s
+}
+
+// the face of a fallback chain r is drawn with
+func faceFor(face font.Face, r rune) font.Face {
+	if f, ok := face.(*fallbackFace); ok {
+		return f.pick(r)
+	}
+	return face
+}
+
+func isMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) || r == 0x200C || r == 0x200D
+}
+
+// the left and right edge of the cluster the byte i of the text is in
+func (s *shapedText) cluster(i int) (x0, x1 fixed.Int26_6, g *shapedGlyph) {
+	for k := range s.glyphs {
+		c := &s.glyphs[k]
+		if i < c.start || i >= c.end {
+			continue
+		}
+		if g == nil {
+			x0, x1, g = c.x, c.x+c.advance, c
+		}
+		x0, x1 = min(x0, c.x), max(x1, c.x+c.advance)
+	}
+	return x0, x1, g
+}
+
+// Where the caret goes before the byte i of the text, on the side the rune
+// starts: the right one in right to left runs. i == len(text) is after the
+// last rune.
+func (s *shapedText) caretX(i int) fixed.Int26_6 {
+	if x0, x1, g := s.cluster(i); g != nil {
+		// inside of a ligature, a part of it for each byte
+		part := (x1 - x0) * fixed.Int26_6(i-g.start) / fixed.Int26_6(g.end-g.start)
+		if g.rtl {
+			return x1 - part
+		}
+		return x0 + part
+	}
+	// after the last rune
+	last := -1
+	for k, g := range s.glyphs {
+		if last < 0 || g.end > s.glyphs[last].end {
+			last = k
+		}
+	}
+	if last < 0 || s.glyphs[last].end > i {
+		return 0
+	}
+	x0, x1, g := s.cluster(s.glyphs[last].start)
+	if g.rtl {
+		return x0
+	}
+	return x1
+}
+
+// The pieces of the screen bytes start to end of the text are on, left to right.
+// Right to left runs in the text break them up.
+func (s *shapedText) ranges(start, end int) [][2]fixed.Int26_6 {
+	var pieces [][2]fixed.Int26_6
+	for _, g := range s.glyphs {
+		if g.start < start || g.start >= end {
+			continue
+		}
+		if n := len(pieces); n > 0 && pieces[n-1][1] == g.x {
+			pieces[n-1][1] = g.x + g.advance
+			continue
+		}
+		pieces = append(pieces, [2]fixed.Int26_6{g.x, g.x + g.advance})
+	}
+	return pieces
+}
+
+// The width of text, like font.MeasureString
+func measureString(face font.Face, text string) fixed.Int26_6 {
+	if s := shape(text, face); s != nil {
+		return s.advance
+	}
+	return font.MeasureString(face, text)
+}
+
+// The bounds of text drawn at the dot 0, like font.BoundString
+func boundString(face font.Face, text string) (fixed.Rectangle26_6, fixed.Int26_6) {
+	s := shape(text, face)
+	if s == nil {
+		return font.BoundString(face, text)
+	}
+	var bounds fixed.Rectangle26_6
+	var buf sfnt.Buffer
+	for _, g := range s.glyphs {
+		var b fixed.Rectangle26_6
+		if g.r >= 0 {
+			b, _, _ = g.face.GlyphBounds(g.r)
+		} else if src, ok := faceSourceOf(g.face); ok {
+			b, _, _ = src.font.font.GlyphBounds(&buf, g.index, src.scale, font.HintingNone)
+		}
+		b = b.Add(fixed.Point26_6{X: g.x}.Add(g.offset))
+		bounds = bounds.Union(b)
+	}
+	return bounds, s.advance
+}
+
+var (
+	glyphBuf  sfnt.Buffer
+	glyphRast vector.Rasterizer
+)
+
+// Like opentype.Face.Glyph, by the index of the glyph. Call it with the lock of the atlas.
+func glyphByIndex(face font.Face, index sfnt.GlyphIndex, dot fixed.Point26_6) (image.Rectangle, image.Image, image.Point) {
+	src, ok := faceSourceOf(face)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}
+	}
+	segments, err := src.font.font.LoadGlyph(&glyphBuf, index, src.scale, nil)
+	if err != nil {
+		return image.Rectangle{}, nil, image.Point{}
+	}
+	b := segments.Bounds().Add(dot)
+	dr := image.Rect(b.Min.X.Floor(), b.Min.Y.Floor(), b.Max.X.Ceil(), b.Max.Y.Ceil())
+	if dr.Empty() {
+		return image.Rectangle{}, nil, image.Point{}
+	}
+
+	// the segments are moved so dr.Min is at 0, 0
+	bias := dot.Sub(fixed.P(dr.Min.X, dr.Min.Y))
+	pt := func(p fixed.Point26_6) (float32, float32) {
+		return float32(p.X+bias.X) / 64, float32(p.Y+bias.Y) / 64
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	glyphRast.Reset(dr.Dx(), dr.Dy())
+	glyphRast.DrawOp = draw.Src
+	for _, seg := range segments {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			glyphRast.MoveTo(pt(seg.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			glyphRast.LineTo(pt(seg.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			x1, y1 := pt(seg.Args[0])
+			x2, y2 := pt(seg.Args[1])
+			glyphRast.QuadTo(x1, y1, x2, y2)
+		case sfnt.SegmentOpCubeTo:
+			x1, y1 := pt(seg.Args[0])
+			x2, y2 := pt(seg.Args[1])
+			x3, y3 := pt(seg.Args[2])
+			glyphRast.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	glyphRast.Draw(mask, mask.Bounds(), image.Opaque, image.ZP)
+	return dr, mask, image.ZP
+}
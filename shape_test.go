@@ -0,0 +1,50 @@
+package tomato
+
+import (
+	"testing"
+
+	"github.com/go-text/typesetting/bidi"
+)
+
+func TestParagraphDir(t *testing.T) {
+	tests := []struct {
+		text string
+		want bidi.Direction
+	}{
+		{"", bidi.LeftToRight},
+		{"hello", bidi.LeftToRight},
+		{"!? 42", bidi.LeftToRight},
+		{"שלום world", bidi.RightToLeft},
+		{"hello שלום", bidi.LeftToRight},
+		{"42 - مرحبا", bidi.RightToLeft},
+		{"\u2067שלום\u2069 world", bidi.LeftToRight}, // the isolate doesn't count
+		{"\u200f!", bidi.RightToLeft},
+	}
+	for _, tt := range tests {
+		if got := paragraphDir(tt.text); got != tt.want {
+			t.Errorf("paragraphDir(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+// the lines of a right to left paragraph go right to left, even the ones without a right to left rune
+func TestWrapParagraphDir(t *testing.T) {
+	face := Font("mono", 10)
+	adv, _ := face.GlyphAdvance('a')
+	text := "שלום abc def!"
+	l := LayoutText(text, face, TextOptions{MaxWidth: 5 * adv.Ceil()})
+	if len(l.Lines) != 3 {
+		t.Fatalf("%q wrapped into %v lines, want 3", text, len(l.Lines))
+	}
+	for _, line := range l.Lines {
+		if line.dir != bidi.RightToLeft {
+			t.Errorf("the line %q goes %v", l.Text[line.Start:line.End], line.dir)
+		}
+	}
+
+	last := l.Lines[2]
+	shaped := shapeDir(l.Text[last.Start:last.End], face, last.dir)
+	if shaped == nil || l.Text[last.Start+shaped.glyphs[0].start] != '!' {
+		t.Errorf("the ! of the last line %q isn't on the left", l.Text[last.Start:last.End])
+	}
+}
@@ -0,0 +1,406 @@
+/*
+   The look of the Ui. A Style has the colors and sizes of all widgets,
+   SetupUi starts with DarkStyle(). The ButtonColorTheme a widget gets
+   still wins over the Style for its colors, with nil the Style is used.
+
+       tomato.SetStyle(tomato.LightStyle())
+
+       s := tomato.CurrentStyle()
+       s.Bg = color.RGBA{120, 20, 20, 255}
+       tomato.PushStyle(s) // only for the next widgets
+       tomato.TextButton(0, "Delete", nil)
+       tomato.PopStyle()
+
+   Styles can be loaded from theme files, json or (a flat subset of) toml,
+   colors are written "#rrggbb" or "#rrggbbaa", the names are the field names
+   in lower case and base picks the preset to start from:
+
+       base = "light"
+       text = "#202020"
+       cornerradius = 6
+       fontname = "sans"
+
+       {"base": "dark", "focus": "#ff8800", "borderwidth": 1}
+*/
+
+package tomato
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+)
+
+type Style struct {
+	Text         color.RGBA
+	TextDisabled color.RGBA
+	Bg           color.RGBA // of the widgets
+	BgHover      color.RGBA
+	BgDown       color.RGBA // pressed
+	BgDisabled   color.RGBA
+	Selection    color.RGBA // selected text, the filled part of sliders
+	Focus        color.RGBA // the ring around the focused widget
+	Border       color.RGBA
+
+	BorderWidth  int // around the widgets, 0 for none
+	CornerRadius int // of the widgets
+	FocusWidth   int // of the focus ring
+	Padding      int // left and right of the text in the widgets
+	Spacing      int // between the elements of new layouts
+
+	PanelBg     color.RGBA
+	TitleBg     color.RGBA
+	TitleActive color.RGBA // of the panel in front
+
+	ScrollbarWidth       int
+	ScrollbarTrack       color.RGBA
+	ScrollbarThumb       color.RGBA
+	ScrollbarThumbActive color.RGBA // hovered or dragged
+
+	FontName string  // see Font(), an unknown name is the default font
+	FontSize float64 // in points, 0 is TEXT_SIZE
+	// not in theme files, wins over FontName if it is set
+	Font font.Face `json:"-"`
+}
+
+func DarkStyle() Style {
+	return Style{
+		Text:         color.RGBA{255, 250, 240, 255}, // Floral White
+		TextDisabled: color.RGBA{145, 141, 138, 255},
+		Bg:           color.RGBA{36, 33, 36, 255}, // Raisin Black
+		BgHover:      color.RGBA{45, 45, 45, 255},
+		BgDown:       color.RGBA{24, 22, 24, 255},
+		BgDisabled:   color.RGBA{36, 33, 36, 255},
+		Selection:    color.RGBA{72, 85, 130, 255},
+		Focus:        color.RGBA{110, 160, 255, 255},
+		Border:       color.RGBA{70, 66, 70, 255},
+
+		FocusWidth: 2,
+		Padding:    X_PADDING,
+		Spacing:    Y_MARGIN,
+
+		PanelBg:     color.RGBA{24, 22, 24, 255},
+		TitleBg:     color.RGBA{36, 33, 36, 255},
+		TitleActive: color.RGBA{72, 85, 130, 255},
+
+		ScrollbarWidth:       12,
+		ScrollbarTrack:       color.RGBA{36, 33, 36, 255},
+		ScrollbarThumb:       color.RGBA{72, 85, 130, 255},
+		ScrollbarThumbActive: color.RGBA{110, 160, 255, 255},
+
+		FontName: "mono",
+		FontSize: TEXT_SIZE,
+	}
+}
+
+func LightStyle() Style {
+	return Style{
+		Text:         color.RGBA{30, 30, 34, 255},
+		TextDisabled: color.RGBA{150, 150, 155, 255},
+		Bg:           color.RGBA{232, 232, 236, 255},
+		BgHover:      color.RGBA{218, 220, 228, 255},
+		BgDown:       color.RGBA{198, 202, 214, 255},
+		BgDisabled:   color.RGBA{240, 240, 242, 255},
+		Selection:    color.RGBA{170, 196, 240, 255},
+		Focus:        color.RGBA{40, 110, 230, 255},
+		Border:       color.RGBA{190, 190, 198, 255},
+
+		BorderWidth:  1,
+		CornerRadius: 4,
+		FocusWidth:   2,
+		Padding:      X_PADDING,
+		Spacing:      Y_MARGIN,
+
+		PanelBg:     color.RGBA{248, 248, 250, 255},
+		TitleBg:     color.RGBA{220, 220, 226, 255},
+		TitleActive: color.RGBA{170, 196, 240, 255},
+
+		ScrollbarWidth:       12,
+		ScrollbarTrack:       color.RGBA{232, 232, 236, 255},
+		ScrollbarThumb:       color.RGBA{180, 184, 196, 255},
+		ScrollbarThumbActive: color.RGBA{40, 110, 230, 255},
+
+		FontName: "mono",
+		FontSize: TEXT_SIZE,
+	}
+}
+
+var stylePresets = map[string]func() Style{
+	"dark":  DarkStyle,
+	"light": LightStyle,
+}
+
+// The style the next widgets use
+func CurrentStyle() Style {
+	return *ui_frame.style()
+}
+
+// Replaces the style of the Ui, the pushed ones stay on top of it
+func SetStyle(s Style) {
+	ui_frame.Style = s
+	ui_frame.syncTheme()
+}
+
+// The next widgets use s, until PopStyle()
+func PushStyle(s Style) {
+	ui_frame.styles = append(ui_frame.styles, s)
+	ui_frame.syncTheme()
+}
+
+func PopStyle() {
+	if len(ui_frame.styles) == 0 {
+		panic("\ntomato ERROR: PopStyle() without PushStyle(...)\n")
+	}
+	ui_frame.styles = ui_frame.styles[:len(ui_frame.styles)-1]
+	ui_frame.syncTheme()
+}
+
+func (frame *Ui_Frame) style() *Style {
+	if len(frame.styles) == 0 {
+		return &frame.Style
+	}
+	return &frame.styles[len(frame.styles)-1]
+}
+
+// DefaultTheme are the colors of the current style, for the widgets without a theme
+func (frame *Ui_Frame) syncTheme() {
+	if changed, version := fontsChanged(frame.fonts); changed {
+		frame.defaultFont = Font("mono", TEXT_SIZE)
+		frame.fonts = version
+	}
+	s := frame.style()
+	face := s.Font
+	if face == nil && s.FontName != "" {
+		size := s.FontSize
+		if size == 0 {
+			size = TEXT_SIZE
+		}
+		face, _ = makeFace(s.FontName, size, DPI)
+	}
+	if face == nil {
+		face = frame.defaultFont
+	}
+	frame.DefaultTheme = ButtonColorTheme{
+		Text:      s.Text,
+		BgUp:      s.Bg,
+		BgHover:   s.BgHover,
+		BgDown:    s.BgDown,
+		FontFace:  face,
+		Selection: s.Selection,
+		Focus:     s.Focus,
+	}
+}
+
+// Loads a theme file, .json or .toml
+func LoadStyleFile(path string) (Style, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Style{}, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return LoadStyleToml(f)
+	}
+	return LoadStyleJson(f)
+}
+
+func LoadStyleJson(r io.Reader) (Style, error) {
+	var raw map[string]any
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return Style{}, err
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		case float64:
+			values[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return Style{}, fmt.Errorf("style: %q has to be a string or a number", k)
+		}
+	}
+	return styleFromValues(values)
+}
+
+// Only key = value lines, # comments and no tables
+func LoadStyleToml(r io.Reader) (Style, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return Style{}, fmt.Errorf("style: line %d: expected key = value", n)
+		}
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			// the # of colors is in the quotes
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				return Style{}, fmt.Errorf("style: line %d: missing \"", n)
+			}
+			value = value[1 : end+1]
+		} else if i := strings.Index(value, "#"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return Style{}, err
+	}
+	return styleFromValues(values)
+}
+
+// Writes s as json theme file, it can be loaded with LoadStyleJson
+func SaveStyleJson(w io.Writer, s Style) error {
+	values := make(map[string]any)
+	v := reflect.ValueOf(s)
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.ToLower(v.Type().Field(i).Name)
+		switch field := v.Field(i).Interface().(type) {
+		case color.RGBA:
+			values[name] = formatColor(field)
+		case int, float64, string:
+			values[name] = field
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(values)
+}
+
+func styleFromValues(values map[string]string) (Style, error) {
+	s := DarkStyle()
+	if base, ok := values["base"]; ok {
+		preset, ok := stylePresets[strings.ToLower(base)]
+		if !ok {
+			return Style{}, fmt.Errorf("style: unknown base %q", base)
+		}
+		s = preset()
+	}
+
+	// sorted, to report the same error every time
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	v := reflect.ValueOf(&s).Elem()
+	for _, key := range keys {
+		if key == "base" {
+			continue
+		}
+		field := v.FieldByNameFunc(func(name string) bool {
+			return strings.EqualFold(name, key)
+		})
+		if !field.IsValid() || field.Type() == reflect.TypeOf((*font.Face)(nil)).Elem() {
+			return Style{}, fmt.Errorf("style: unknown key %q", key)
+		}
+		value := values[key]
+		switch field.Interface().(type) {
+		case color.RGBA:
+			c, err := parseColor(value)
+			if err != nil {
+				return Style{}, fmt.Errorf("style: %s: %w", key, err)
+			}
+			field.Set(reflect.ValueOf(c))
+		case int:
+			i, err := strconv.Atoi(value)
+			if err != nil {
+				return Style{}, fmt.Errorf("style: %s: %w", key, err)
+			}
+			field.SetInt(int64(i))
+		case float64:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return Style{}, fmt.Errorf("style: %s: %w", key, err)
+			}
+			field.SetFloat(f)
+		case string:
+			field.SetString(value)
+		}
+	}
+	return s, nil
+}
+
+// "#rrggbb" or "#rrggbbaa", the colors are not premultiplied in files
+func parseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return color.RGBA{}, fmt.Errorf("bad color %q, use #rrggbb or #rrggbbaa", s)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("bad color %q, use #rrggbb or #rrggbbaa", s)
+	}
+	c := color.NRGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}
+	return color.RGBAModel.Convert(c).(color.RGBA), nil
+}
+
+func formatColor(c color.RGBA) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
+
+// draws a widget image made with decorate
+func drawWidget(target image.Rectangle, img image.Image) {
+	if ui_frame.style().CornerRadius > 0 {
+		current.toDrawOver(target, img)
+	} else {
+		ToDraw(target, img)
+	}
+}
+
+// Draws the border of the style around img and cuts off the rounded corners.
+// Draw it with toDrawOver then, the corners are transparent.
+func decorate(img *image.RGBA, s *Style) {
+	b := img.Bounds()
+	if w := s.BorderWidth; w > 0 {
+		border := image.NewUniform(s.Border)
+		draw.Draw(img, image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Min.Y+w), border, image.ZP, draw.Src)
+		draw.Draw(img, image.Rect(b.Min.X, b.Max.Y-w, b.Max.X, b.Max.Y), border, image.ZP, draw.Src)
+		draw.Draw(img, image.Rect(b.Min.X, b.Min.Y, b.Min.X+w, b.Max.Y), border, image.ZP, draw.Src)
+		draw.Draw(img, image.Rect(b.Max.X-w, b.Min.Y, b.Max.X, b.Max.Y), border, image.ZP, draw.Src)
+	}
+
+	r := Min(s.CornerRadius, Min(b.Dx(), b.Dy())/2)
+	if r <= 0 {
+		return
+	}
+	rf := float64(r)
+	for y := 0; y < r; y++ {
+		for x := 0; x < r; x++ {
+			dx, dy := rf-float64(x)-0.5, rf-float64(y)-0.5
+			if dx*dx+dy*dy <= rf*rf {
+				continue
+			}
+			img.SetRGBA(b.Min.X+x, b.Min.Y+y, color.RGBA{})
+			img.SetRGBA(b.Max.X-1-x, b.Min.Y+y, color.RGBA{})
+			img.SetRGBA(b.Min.X+x, b.Max.Y-1-y, color.RGBA{})
+			img.SetRGBA(b.Max.X-1-x, b.Max.Y-1-y, color.RGBA{})
+		}
+	}
+}
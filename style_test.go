@@ -0,0 +1,77 @@
+package tomato
+
+import (
+	"bytes"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadStyleToml(t *testing.T) {
+	tests := []struct {
+		toml string
+		want func() Style
+		err  bool
+	}{
+		{toml: "", want: DarkStyle},
+		{toml: "# only a comment\n\n", want: DarkStyle},
+		{toml: `base = "light"`, want: LightStyle},
+		{toml: `base = "Dark"`, want: DarkStyle},
+		{toml: "base = \"light\"\ntext = \"#202020\"", want: func() Style {
+			s := LightStyle()
+			s.Text = color.RGBA{32, 32, 32, 255}
+			return s
+		}},
+		{toml: `focus = "#ff000080" # half transparent`, want: func() Style {
+			s := DarkStyle()
+			s.Focus = color.RGBA{128, 0, 0, 128}
+			return s
+		}},
+		{toml: "  CornerRadius=6  # round\nfontname = \"sans # bold\"\nfontsize = 13.5", want: func() Style {
+			s := DarkStyle()
+			s.CornerRadius = 6
+			s.FontName = "sans # bold"
+			s.FontSize = 13.5
+			return s
+		}},
+		{toml: "[colors]", err: true},
+		{toml: `text = "#202020`, err: true},
+		{toml: `text = "#2020"`, err: true},
+		{toml: `text = "red"`, err: true},
+		{toml: `cornerradius = 6.5`, err: true},
+		{toml: `fontsize = big`, err: true},
+		{toml: `base = "solarized"`, err: true},
+		{toml: `nope = 1`, err: true},
+		{toml: `font = "mono"`, err: true},
+	}
+	for _, tt := range tests {
+		got, err := LoadStyleToml(strings.NewReader(tt.toml))
+		if (err != nil) != tt.err {
+			t.Errorf("LoadStyleToml(%q) error %v, want error %v", tt.toml, err, tt.err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want()) {
+			t.Errorf("LoadStyleToml(%q) = %+v, want %+v", tt.toml, got, tt.want())
+		}
+	}
+}
+
+// what SaveStyleJson writes LoadStyleJson reads
+func TestStyleJson(t *testing.T) {
+	s := LightStyle()
+	s.Focus = color.RGBA{128, 0, 0, 128}
+	s.FontName, s.FontSize = "sans", 13.5
+
+	var buf bytes.Buffer
+	if err := SaveStyleJson(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadStyleJson(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("loaded %+v, saved %+v", got, s)
+	}
+}
@@ -0,0 +1,429 @@
+/*
+   Text layout: breaking text into lines that fit a width and placing them.
+
+       opts := tomato.TextOptions{MaxWidth: 300, Align: tomato.TextJustify}
+       size := tomato.MeasureText(text, face, opts) // before rendering
+       img := tomato.RenderTextOptions(text, textColor, bgColor, face, opts)
+
+   '\n' (and the other line separators of Unicode) always starts a new line.
+   Lines are broken where the Unicode line breaking algorithm (UAX #14) allows
+   it: between words, after hyphens, between ideographs (Chinese, Japanese,
+   Korean), not before closing punctuation, not in numbers like 1,000.
+   A word wider than MaxWidth is broken between its graphemes (UAX #29: a
+   letter with its accents, an emoji sequence), never inside of one.
+   Spaces at the end of a line don't count for its width.
+   Lines with right to left text are reordered and shaped, see the shaping.
+*/
+
+package tomato
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-text/typesetting/bidi"
+	"github.com/go-text/typesetting/segmenter"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+type TextAlign uint8
+
+const (
+	TextLeft TextAlign = iota
+	TextCenter
+	TextRight
+	TextJustify // both edges straight, except the last line of a paragraph
+)
+
+type TextOptions struct {
+	MaxWidth    int     // in pixels, 0 doesn't wrap
+	LineSpacing float64 // times the height of a line, 0 is 1
+	Align       TextAlign
+
+	// for the rich text spans that pick a font by Size, Bold or Italic without a FontName,
+	// if the face doesn't come from Font(...)
+	FontName string  // "mono" if empty
+	FontSize float64 // TEXT_SIZE if 0
+}
+
+type TextLine struct {
+	Start, End int            // byte offsets of the line in the text, without the line break and the spaces at the end
+	Width      int            // in pixels
+	Baseline   int            // from the top of the text
+	last       bool           // of its paragraph, not justified
+	dir        bidi.Direction // of its paragraph
+}
+
+type TextLayout struct {
+	Text  string
+	Lines []TextLine
+	Size  Size // of the lines, MaxWidth isn't included
+
+	spans []textSpan // cover the text, in order
+	opts  TextOptions
+}
+
+// a Span with its place in the text and its face
+type textSpan struct {
+	Span
+	start, end int
+	face       font.Face
+}
+
+// Breaks text into lines, see TextOptions
+func LayoutText(text string, face font.Face, opts TextOptions) *TextLayout {
+	return layoutSpans([]textSpan{{Span: Span{Text: text}, end: len(text), face: face}}, face, opts)
+}
+
+// the spans have their faces already
+func layoutSpans(spans []textSpan, face font.Face, opts TextOptions) *TextLayout {
+	text := ""
+	for _, s := range spans {
+		text += s.Text
+	}
+	l := &TextLayout{Text: text, spans: spans, opts: opts}
+
+	start := 0
+	for start <= len(text) {
+		// one paragraph
+		end, next := nextLineBreak(text, start)
+		l.wrap(start, end)
+		l.Lines[len(l.Lines)-1].last = true
+		if next < 0 {
+			break
+		}
+		start = next
+	}
+
+	spacing := opts.LineSpacing
+	if spacing == 0 {
+		spacing = 1
+	}
+	top := 0
+	for i := range l.Lines {
+		line := &l.Lines[i]
+		// the biggest font in the line decides
+		var ascent, height fixed.Int26_6
+		for _, s := range l.spans {
+			overlaps := s.start < line.End && s.end > line.Start
+			// an empty line gets the font of where it is
+			empty := line.Start == line.End && s.start <= line.Start && line.Start <= s.end
+			if overlaps || empty {
+				m := s.face.Metrics()
+				ascent = max(ascent, m.Ascent)
+				height = max(height, m.Height)
+			}
+		}
+		if height == 0 {
+			m := face.Metrics()
+			ascent, height = m.Ascent, m.Height
+		}
+		line.Baseline = top + ascent.Ceil()
+		l.Size.X = Max(l.Size.X, line.Width)
+		l.Size.Y = top + height.Ceil()
+		top += int(math.Round(float64(height) * spacing / 64))
+	}
+	return l
+}
+
+// The size LayoutText gives
+func MeasureText(text string, face font.Face, opts TextOptions) Size {
+	return LayoutText(text, face, opts).Size
+}
+
+// Like RenderText with TextOptions, the image is as wide as MaxWidth at least
+func RenderTextOptions(text string, textColor, bgColor color.RGBA, face font.Face, opts TextOptions) draw.Image {
+	return LayoutText(text, face, opts).render(textColor, bgColor)
+}
+
+func (l *TextLayout) render(textColor, bgColor color.RGBA) draw.Image {
+	img := image.NewRGBA(image.Rect(0, 0, Max(l.Size.X, l.opts.MaxWidth), l.Size.Y))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bgColor), image.ZP, draw.Src)
+	l.Draw(img, image.ZP, textColor)
+	return img
+}
+
+// Draws the lines with their top left at at, c is the color of the spans without one
+func (l *TextLayout) Draw(dst draw.Image, at image.Point, c color.RGBA) {
+	width := Max(l.Size.X, l.opts.MaxWidth)
+	for _, line := range l.Lines {
+		x := at.X
+		switch l.opts.Align {
+		case TextCenter:
+			x += (width - line.Width) / 2
+		case TextRight:
+			x += width - line.Width
+		}
+		dot := fixed.P(x, at.Y+line.Baseline)
+
+		// the space left goes between the words
+		// @Todo right to left lines aren't justified, the words would go left to right.
+		// Their spans are drawn in logical order too.
+		var extra, gaps fixed.Int26_6
+		if l.opts.Align == TextJustify && !line.last && line.Width < width && line.dir != bidi.RightToLeft && !hasRTL(l.Text[line.Start:line.End]) {
+			extra = fixed.I(width - line.Width)
+			gaps = fixed.Int26_6(wordGaps(l.Text[line.Start:line.End]))
+		}
+		gap := fixed.Int26_6(0)
+
+		for _, s := range l.spans {
+			a, b := Max(s.start, line.Start), Min(s.end, line.End)
+			if a >= b {
+				continue
+			}
+			color := c
+			if s.Color.A != 0 {
+				color = s.Color
+			}
+			for a < b {
+				// a piece of the span, up to the next gap between words if justified
+				end := b
+				if gaps > 0 {
+					end = nextWordStart(l.Text, a, b)
+				}
+				if gaps > 0 && a > line.Start && startsWord(l.Text, a) {
+					gap++
+					dot.X += extra*gap/gaps - extra*(gap-1)/gaps
+				}
+				if s.Background.A != 0 {
+					m := s.face.Metrics()
+					top := dot.Y.Floor() - m.Ascent.Ceil()
+					r := image.Rect(dot.X.Floor(), top, (dot.X + l.advance(a, end, false, line.dir)).Ceil(), top+m.Height.Ceil())
+					draw.Draw(dst, r, image.NewUniform(s.Background), image.ZP, draw.Over)
+				}
+				from := dot.X
+				dot = atlas.drawStringDir(dst, dot, l.Text[a:end], s.face, line.dir, color)
+				s.strokes(dst, from, dot.X, at.Y+line.Baseline, color)
+				a = end
+			}
+		}
+	}
+}
+
+// the lines of the paragraph text[start:end]
+func (l *TextLayout) wrap(start, end int) {
+	text, maxW := l.Text, fixed.I(l.opts.MaxWidth)
+	// once for the paragraph, a line alone may guess it wrong
+	dir := paragraphDir(text[start:end])
+
+	if maxW == 0 {
+		l.addLine(start, end, dir)
+		return
+	}
+
+	// The shaped paragraph, the letters of Arabic look different alone.
+	// A line is about as wide as its clusters in there, addLine measures it exactly.
+	var widths []fixed.Int26_6
+	if dir == bidi.RightToLeft || needsShaping(text[start:end]) {
+		widths = l.clusterWidths(start, end, dir)
+	}
+	sum := func(a, b int) fixed.Int26_6 {
+		var w fixed.Int26_6
+		for _, c := range widths[a-start : b-start] {
+			w += c
+		}
+		return w
+	}
+
+	graphemes, breaks := segment(text[start:end])
+	lineStart := start
+	lastBreak := -1 // where the next line can start
+	var x fixed.Int26_6
+	for i := start; i < end; {
+		n := 1
+		for !graphemes[i-start+n] {
+			n++
+		}
+		r, _ := utf8.DecodeRuneInString(text[i:])
+		var gx fixed.Int26_6
+		if widths != nil {
+			gx = x + sum(i, i+n)
+		} else {
+			gx = x + l.advance(i, i+n, i > lineStart, dir)
+		}
+
+		// spaces may go over the edge, they aren't drawn at the end of the line
+		if maxW > 0 && gx > maxW && !isSpace(r) && i > lineStart {
+			if lastBreak > lineStart {
+				l.addLine(lineStart, lastBreak, dir)
+				lineStart = lastBreak
+			} else {
+				// no place for a break, between the graphemes then
+				l.addLine(lineStart, i, dir)
+				lineStart = i
+			}
+			lastBreak = -1
+			if widths != nil {
+				x = sum(lineStart, i+n)
+			} else {
+				x = l.advance(lineStart, i+n, false, dir)
+			}
+		} else {
+			x = gx
+		}
+
+		i += n
+		if i < end && breaks[i-start] {
+			lastBreak = i
+		}
+	}
+	l.addLine(lineStart, end, dir)
+}
+
+// How much every byte of the paragraph text[start:end] adds to the width of
+// a line: the advance of the glyphs of the cluster starting there, shaped with
+// the rest of the span. The other bytes of a cluster add nothing.
+func (l *TextLayout) clusterWidths(start, end int, dir bidi.Direction) []fixed.Int26_6 {
+	widths := make([]fixed.Int26_6, end-start)
+	for _, s := range l.spans {
+		a, b := Max(s.start, start), Min(s.end, end)
+		if a >= b {
+			continue
+		}
+		if shaped := shapeDir(l.Text[a:b], s.face, dir); shaped != nil {
+			for _, g := range shaped.glyphs {
+				widths[a-start+g.start] += g.advance
+			}
+			continue
+		}
+		prev := rune(-1)
+		for i, r := range l.Text[a:b] {
+			if prev >= 0 {
+				widths[a-start+i] += s.face.Kern(prev, r)
+			}
+			adv, _ := s.face.GlyphAdvance(r)
+			widths[a-start+i] += adv
+			prev = r
+		}
+	}
+	return widths
+}
+
+// the width of text[start:end] with the faces of the spans, like font.MeasureString.
+// With kernStart the kerning to the rune before start counts too, not for shaped text.
+// dir is the one of the paragraph.
+func (l *TextLayout) advance(start, end int, kernStart bool, dir bidi.Direction) fixed.Int26_6 {
+	var x fixed.Int26_6
+	for _, s := range l.spans {
+		a, b := Max(s.start, start), Min(s.end, end)
+		if a >= b {
+			continue
+		}
+		if shaped := shapeDir(l.Text[a:b], s.face, dir); shaped != nil {
+			x += shaped.advance
+			continue
+		}
+		prev := rune(-1)
+		if kernStart && a == start && a > s.start {
+			prev, _ = utf8.DecodeLastRuneInString(l.Text[:a])
+		}
+		for _, r := range l.Text[a:b] {
+			if prev >= 0 {
+				x += s.face.Kern(prev, r)
+			}
+			adv, _ := s.face.GlyphAdvance(r)
+			x += adv
+			prev = r
+		}
+	}
+	return x
+}
+
+func (l *TextLayout) addLine(start, end int, dir bidi.Direction) {
+	// the spaces at the end hang over
+	end = start + len(strings.TrimRightFunc(l.Text[start:end], isSpace))
+	l.Lines = append(l.Lines, TextLine{
+		Start: start,
+		End:   end,
+		Width: l.advance(start, end, false, dir).Ceil(),
+		dir:   dir,
+	})
+}
+
+// the number of places between words in a line
+func wordGaps(line string) int {
+	return Max(len(strings.FieldsFunc(line, isSpace))-1, 0)
+}
+
+// true if a word starts at text[i]
+func startsWord(text string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	last, _ := utf8.DecodeLastRuneInString(text[:i])
+	return !isSpace(r) && isSpace(last)
+}
+
+// where the next word after a starts, b if there is none before it
+func nextWordStart(text string, a, b int) int {
+	for i := range text[a:b] {
+		if i > 0 && startsWord(text, a+i) {
+			return a + i
+		}
+	}
+	return b
+}
+
+// the end of the paragraph at start and where the next one starts, -1 if there is none
+func nextLineBreak(text string, start int) (int, int) {
+	for i, r := range text[start:] {
+		i += start
+		switch r {
+		case '\r':
+			if strings.HasPrefix(text[i:], "\r\n") {
+				return i, i + 2
+			}
+			return i, i + 1
+		case '\n', '\v', '\f', 0x85, 0x2028, 0x2029:
+			return i, i + utf8.RuneLen(r)
+		}
+	}
+	return len(text), -1
+}
+
+func isSpace(r rune) bool {
+	return r != 0xA0 && r != 0x202F && unicode.IsSpace(r) || r == 0x200B
+}
+
+// Where the graphemes of text end (UAX #29: a letter with its accents, an emoji
+// sequence, ...) and where a line may start (UAX #14), by byte offset into text.
+// Both are true at len(text).
+// @Speed a segmenter for every paragraph
+func segment(text string) (graphemes, breaks []bool) {
+	var runes []rune
+	var offsets []int // of every rune in text, and the end
+	for i, r := range text {
+		runes = append(runes, r)
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(text))
+
+	graphemes = make([]bool, len(text)+1)
+	breaks = make([]bool, len(text)+1)
+	var seg segmenter.Segmenter
+	seg.Init(runes)
+	for it := seg.GraphemeIterator(); it.Next(); {
+		g := it.Grapheme()
+		end := g.Offset + len(g.Text)
+		// @Todo the segmenter (go-text v0.3.5) doesn't start an emoji sequence right
+		// after another one, it breaks 👩‍💻👩‍💻 after the second zero width joiner
+		if n := len(g.Text); n > 1 && g.Text[n-1] == 0x200D && isPictographic(g.Text[0]) && end < len(runes) && isPictographic(runes[end]) {
+			continue
+		}
+		graphemes[offsets[end]] = true
+	}
+	for it := seg.LineIterator(); it.Next(); {
+		line := it.Line()
+		breaks[offsets[line.Offset+len(line.Text)]] = true
+	}
+	graphemes[len(text)], breaks[len(text)] = true, true
+	return graphemes, breaks
+}
+
+func isPictographic(r rune) bool {
+	return r >= 0x1F000 && r <= 0x1FAFF || r >= 0x2600 && r <= 0x27BF || unicode.Is(unicode.So, r)
+}
@@ -0,0 +1,89 @@
+package tomato
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayoutTextWrap(t *testing.T) {
+	face := Font("mono", 10)
+	adv, _ := face.GlyphAdvance('a')
+	char := adv.Ceil() // all glyphs of mono are as wide
+
+	tests := []struct {
+		text  string
+		chars int // MaxWidth, 0 doesn't wrap
+		lines []string
+	}{
+		{"", 0, []string{""}},
+		{"hello world", 0, []string{"hello world"}},
+		{"hello world", 11, []string{"hello world"}},
+		{"hello world", 10, []string{"hello", "world"}},
+		{"hello world", 5, []string{"hello", "world"}},
+		{"a b c d", 3, []string{"a b", "c d"}},
+		{"one  two", 4, []string{"one", "two"}},               // spaces hang over the edge
+		{"verylongword", 5, []string{"veryl", "ongwo", "rd"}}, // no place to break
+		{"x", 1, []string{"x"}},
+		{"ab", 1, []string{"a", "b"}}, // at least one grapheme a line
+		{"well-known", 6, []string{"well-", "known"}},
+		{"a\nb", 0, []string{"a", "b"}},
+		{"a\r\nb\n\nc", 0, []string{"a", "b", "", "c"}},
+		{"end\n", 0, []string{"end", ""}},
+		{"e\u0301e\u0301", 1, []string{"e\u0301", "e\u0301"}}, // graphemes stay together
+		{"日本語の文章", 2, []string{"日本", "語の", "文章"}},             // between ideographs
+		{"日本。語", 2, []string{"日", "本。", "語"}},                 // not before closing punctuation
+		{"ab (cd) ef", 7, []string{"ab (cd)", "ef"}},
+		{"ab (cd) ef", 5, []string{"ab", "(cd)", "ef"}}, // not after ( or before )
+		{"costs 1,000 now", 9, []string{"costs", "1,000 now"}},
+		{"1,000,000", 4, []string{"1,00", "0,00", "0"}}, // only between the graphemes
+		{"\U0001F469\u200D\U0001F4BB\U0001F469\u200D\U0001F4BB", 1, []string{"\U0001F469\u200D\U0001F4BB", "\U0001F469\u200D\U0001F4BB"}},
+		{"\U0001F4BB\U0001F469\u200D\U0001F469\u200D\U0001F467", 1, []string{"\U0001F4BB", "\U0001F469\u200D\U0001F469\u200D\U0001F467"}},
+		{"\U0001F44D\U0001F3FD\U0001F1E9\U0001F1EA\U0001F1EB\U0001F1F7", 1, []string{"\U0001F44D\U0001F3FD", "\U0001F1E9\U0001F1EA", "\U0001F1EB\U0001F1F7"}}, // skin tone, flags
+	}
+	for _, tt := range tests {
+		l := LayoutText(tt.text, face, TextOptions{MaxWidth: tt.chars * char})
+		var lines []string
+		for _, line := range l.Lines {
+			lines = append(lines, l.Text[line.Start:line.End])
+		}
+		if !reflect.DeepEqual(lines, tt.lines) {
+			t.Errorf("LayoutText(%q) at %v chars gave %q, want %q", tt.text, tt.chars, lines, tt.lines)
+		}
+		for _, line := range l.Lines {
+			text := l.Text[line.Start:line.End]
+			// only a single grapheme may be wider
+			ends, _ := segment(text)
+			graphemes := 0
+			for _, end := range ends[1:] {
+				if end {
+					graphemes++
+				}
+			}
+			if tt.chars > 0 && line.Width > tt.chars*char && graphemes > 1 {
+				t.Errorf("LayoutText(%q): the line %q is %v wide, more than %v", tt.text, text, line.Width, tt.chars*char)
+			}
+		}
+	}
+}
+
+func TestLayoutTextSize(t *testing.T) {
+	face := Font("mono", 10)
+	m := face.Metrics()
+	adv, _ := face.GlyphAdvance('a')
+
+	l := LayoutText("ab\nabcd", face, TextOptions{})
+	if want := (4 * adv).Ceil(); l.Size.X != want {
+		t.Errorf("width %v, want %v", l.Size.X, want)
+	}
+	if want := m.Height.Ceil() + m.Height.Round(); l.Size.Y != want {
+		t.Errorf("height %v, want %v", l.Size.Y, want)
+	}
+	if l.Lines[0].Baseline != m.Ascent.Ceil() {
+		t.Errorf("the first baseline is at %v, want %v", l.Lines[0].Baseline, m.Ascent.Ceil())
+	}
+
+	double := LayoutText("ab\nabcd", face, TextOptions{LineSpacing: 2})
+	if want := l.Lines[1].Baseline + m.Height.Round(); double.Lines[1].Baseline != want {
+		t.Errorf("with LineSpacing 2 the second baseline is at %v, want %v", double.Lines[1].Baseline, want)
+	}
+}
@@ -0,0 +1,577 @@
+/*
+   Text fields, single and multi line:
+
+       tomato.TextInput("Name", &name, nil)
+       tomato.TextInputMulti("Notes", &notes, 5, nil)
+
+   They return true when the text was changed. The label is the id of the
+   field (see GetID) and shown greyed out while the field is empty.
+
+   A click into a field focuses it, the focused field takes the typed runes and
+       Left/Right     (+Ctrl by words)
+       Up/Down        multi line only
+       Home/End       of the line (+Ctrl of the text)
+       Backspace/Delete
+       Enter          new line, multi line only
+       Ctrl+A         select all
+       Ctrl+C/X/V     copy, cut, paste (see ClipboardText)
+       Ctrl+Z         undo
+       Ctrl+Y         redo, also Ctrl+Shift+Z
+   Shift with the movement keys, or dragging the mouse, selects.
+   Tab moves on to the next widget, see focus.go.
+
+   The keys still arrive in Events(), check WantsKeyboard() before using
+   them for your own shortcuts.
+*/
+
+package tomato
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const MAX_UNDO int = 100
+
+// the state of a text field between frames
+type textInput struct {
+	text   string // what *text was when we saw it the last time
+	runes  []rune
+	caret  int // in runes
+	anchor int // the other end of the selection, == caret if nothing is selected
+	scroll image.Point
+
+	undo, redo []textSnapshot
+	typing     bool // the last edit was typing, one undo step for all of it
+
+	used bool // in this frame, the ones not used are evicted at the end of the frame
+}
+
+type textSnapshot struct {
+	runes         []rune
+	caret, anchor int
+}
+
+// returns true if the text was changed
+func TextInput(label string, text *string, theme *ButtonColorTheme) bool { // use nil for default theme
+	return textInputWidget(label, text, 1, theme)
+}
+
+// A text field with room for lines lines, it takes Enter as new line
+func TextInputMulti(label string, text *string, lines int, theme *ButtonColorTheme) bool { // use nil for default theme
+	return textInputWidget(label, text, Max(lines, 1), theme)
+}
+
+func textInputWidget(label string, text *string, lines int, theme *ButtonColorTheme) bool {
+	if len(ui_frame.Layouts) == 0 {
+		panic("\ntomato ERROR: call ui.Layout(0, ui.Vertical, image.Rect(0,0,100,100)) at least before TextInput!\n")
+	}
+
+	lay := &ui_frame.Layouts[ui_frame.Active]
+	id := GetID(label)
+	multi := lines > 1
+	persistValue(id, text)
+
+	if theme == nil {
+		theme = &ui_frame.DefaultTheme
+	}
+	face := theme.FontFace
+	lineH := face.Metrics().Height.Ceil()
+	pad := ui_frame.style().Padding
+
+	want := Size{0, widgetHeight(face)}
+	if multi {
+		want.Y = Max(want.Y, lines*lineH+2*Y_MARGIN)
+	}
+	if lay.Ori == Horizontal {
+		want.X = font.MeasureString(face, "MMMMMMMMMMMM").Ceil() + 2*pad
+	}
+	target := lay.next(want)
+
+	// where the text goes, relative to target
+	inner := image.Rect(pad, Y_MARGIN, target.Dx()-pad, target.Dy()-Y_MARGIN)
+	if !multi {
+		inner.Min.Y = (target.Dy() - lineH) / 2
+		inner.Max.Y = inner.Min.Y + lineH
+	}
+
+	in, ok := ui_frame.inputs[id]
+	if !ok {
+		in = &textInput{}
+		ui_frame.inputs[id] = in
+	}
+	in.used = true
+
+	// the program changed the text
+	if !ok || *text != in.text {
+		in.setText([]rune(*text))
+		in.text = *text
+	}
+
+	// mouse
+	mouse := image.Pt(current.MouseX, current.MouseY)
+	local := mouse.Sub(target.Min).Sub(inner.Min).Add(in.scroll)
+	focused := ui_frame.focusable(id, target)
+	if clickedIn(target) {
+		ui_frame.dragging = id
+		in.typing = false
+		in.caret = in.indexAt(local, face, lineH)
+		if current.Mods&ModShift == 0 {
+			in.anchor = in.caret
+		}
+	}
+	if ui_frame.dragging == id {
+		if current.MouseDownL {
+			in.caret = in.indexAt(local, face, lineH)
+		} else {
+			ui_frame.dragging = 0
+		}
+	}
+
+	// keyboard
+	changed := false
+	if focused {
+		for _, ev := range ui_frame.takeKeyboard() {
+			if in.handle(ev, multi, face) {
+				changed = true
+			}
+		}
+	}
+	if changed {
+		in.text = string(in.runes)
+		*text = in.text
+	}
+
+	// keep the caret visible
+	caretPos := in.pos(in.caret, face, lineH)
+	if caretPos.X < in.scroll.X {
+		in.scroll.X = caretPos.X
+	}
+	if caretPos.X+2 > in.scroll.X+inner.Dx() {
+		in.scroll.X = caretPos.X + 2 - inner.Dx()
+	}
+	if caretPos.Y < in.scroll.Y {
+		in.scroll.Y = caretPos.Y
+	}
+	if caretPos.Y+lineH > in.scroll.Y+inner.Dy() {
+		in.scroll.Y = caretPos.Y + lineH - inner.Dy()
+	}
+
+	// @Speed only render it again if something changed
+	drawWidget(target, in.render(target.Size(), inner, label, focused, theme, lineH))
+	if focused {
+		drawFocusRing(target, theme)
+	}
+
+	return changed
+}
+
+// forgets the text fields that were not there this frame, called by DrawUi
+func (frame *Ui_Frame) endTextInputs() {
+	for id, in := range frame.inputs {
+		if !in.used {
+			delete(frame.inputs, id)
+			continue
+		}
+		in.used = false
+	}
+}
+
+func (in *textInput) setText(runes []rune) {
+	in.runes = runes
+	in.caret = Min(in.caret, len(runes))
+	in.anchor = Min(in.anchor, len(runes))
+}
+
+func (in *textInput) selection() (int, int) {
+	return Min(in.caret, in.anchor), Max(in.caret, in.anchor)
+}
+
+// call before every edit
+func (in *textInput) save(typing bool) {
+	if !typing || !in.typing {
+		in.undo = append(in.undo, in.snapshot())
+		if len(in.undo) > MAX_UNDO {
+			in.undo = in.undo[1:]
+		}
+	}
+	in.typing = typing
+	in.redo = in.redo[:0]
+}
+
+func (in *textInput) snapshot() textSnapshot {
+	return textSnapshot{
+		runes:  append([]rune(nil), in.runes...),
+		caret:  in.caret,
+		anchor: in.anchor,
+	}
+}
+
+func (in *textInput) restore(s textSnapshot) {
+	in.runes = s.runes
+	in.caret = s.caret
+	in.anchor = s.anchor
+	in.typing = false
+}
+
+// replaces the selection with r
+func (in *textInput) insert(r []rune) {
+	start, end := in.selection()
+	runes := make([]rune, 0, len(in.runes)-(end-start)+len(r))
+	runes = append(runes, in.runes[:start]...)
+	runes = append(runes, r...)
+	runes = append(runes, in.runes[end:]...)
+	in.runes = runes
+	in.caret = start + len(r)
+	in.anchor = in.caret
+}
+
+// returns true if the text changed
+func (in *textInput) handle(ev Ev, multi bool, face font.Face) bool {
+	if ev.Kind == RuneTyped {
+		in.save(true)
+		in.insert([]rune{ev.Rune})
+		return true
+	}
+
+	ctrl := ev.Mods&(ModCtrl|ModSuper) != 0
+	shift := ev.Mods&ModShift != 0
+	start, end := in.selection()
+	selected := start != end
+
+	// moves the caret, and the selection with it unless Shift is down
+	move := func(to int) {
+		in.caret = Max(0, Min(to, len(in.runes)))
+		if !shift {
+			in.anchor = in.caret
+		}
+		in.typing = false
+	}
+
+	switch ev.Key {
+	case Left:
+		switch {
+		case selected && !shift:
+			move(start)
+		case ctrl:
+			move(in.wordLeft(in.caret))
+		default:
+			move(in.caret - 1)
+		}
+	case Right:
+		switch {
+		case selected && !shift:
+			move(end)
+		case ctrl:
+			move(in.wordRight(in.caret))
+		default:
+			move(in.caret + 1)
+		}
+	case Up, Down:
+		if !multi {
+			return false
+		}
+		row, _ := in.rowCol(in.caret)
+		if ev.Key == Up {
+			row--
+		} else {
+			row++
+		}
+		starts := in.lineStarts()
+		if row < 0 {
+			move(0)
+		} else if row >= len(starts) {
+			move(len(in.runes))
+		} else {
+			x := in.lineX(in.caret, face)
+			move(in.colAt(starts[row], x, face))
+		}
+	case Home:
+		if ctrl {
+			move(0)
+		} else {
+			row, _ := in.rowCol(in.caret)
+			move(in.lineStarts()[row])
+		}
+	case End:
+		if ctrl {
+			move(len(in.runes))
+		} else {
+			move(in.lineEnd(in.caret))
+		}
+	case Backspace, Delete:
+		from := in.anchor
+		if !selected {
+			switch {
+			case ev.Key == Backspace && ctrl:
+				from = in.wordLeft(in.caret)
+			case ev.Key == Backspace:
+				from = Max(in.caret-1, 0)
+			case ctrl:
+				from = in.wordRight(in.caret)
+			default:
+				from = Min(in.caret+1, len(in.runes))
+			}
+			if from == in.caret {
+				return false
+			}
+		}
+		in.save(false)
+		in.anchor = from
+		in.insert(nil)
+		return true
+	case Enter, KpEnter:
+		if !multi {
+			return false
+		}
+		in.save(true)
+		in.insert([]rune{'\n'})
+		return true
+	case KeyA:
+		if ctrl {
+			in.anchor = 0
+			in.caret = len(in.runes)
+		}
+	case KeyC, KeyX:
+		if !ctrl || !selected {
+			return false
+		}
+		SetClipboardText(string(in.runes[start:end]))
+		if ev.Key == KeyX {
+			in.save(false)
+			in.insert(nil)
+			return true
+		}
+	case KeyV:
+		if !ctrl {
+			return false
+		}
+		paste := []rune(ClipboardText())
+		if !multi {
+			for i, r := range paste {
+				if r == '\n' || r == '\r' {
+					paste[i] = ' '
+				}
+			}
+		}
+		in.save(false)
+		in.insert(paste)
+		return true
+	case KeyZ, KeyY:
+		if !ctrl {
+			return false
+		}
+		redo := ev.Key == KeyY || shift
+		from, to := &in.undo, &in.redo
+		if redo {
+			from, to = &in.redo, &in.undo
+		}
+		if len(*from) == 0 {
+			return false
+		}
+		*to = append(*to, in.snapshot())
+		in.restore((*from)[len(*from)-1])
+		*from = (*from)[:len(*from)-1]
+		return true
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// the start of the word before i
+func (in *textInput) wordLeft(i int) int {
+	for i > 0 && !isWordRune(in.runes[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(in.runes[i-1]) {
+		i--
+	}
+	return i
+}
+
+// the end of the word after i
+func (in *textInput) wordRight(i int) int {
+	for i < len(in.runes) && !isWordRune(in.runes[i]) {
+		i++
+	}
+	for i < len(in.runes) && isWordRune(in.runes[i]) {
+		i++
+	}
+	return i
+}
+
+// the index of the first rune of every line
+func (in *textInput) lineStarts() []int {
+	starts := []int{0}
+	for i, r := range in.runes {
+		if r == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// the end of the line of i, before the '\n'
+func (in *textInput) lineEnd(i int) int {
+	for i < len(in.runes) && in.runes[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+func (in *textInput) rowCol(i int) (int, int) {
+	row, start := 0, 0
+	for j := 0; j < i; j++ {
+		if in.runes[j] == '\n' {
+			row++
+			start = j + 1
+		}
+	}
+	return row, i - start
+}
+
+// x of the rune i from the start of its line, in pixels
+func (in *textInput) lineX(i int, face font.Face) int {
+	_, col := in.rowCol(i)
+	start := i - col
+	if shaped := shape(string(in.runes[start:in.lineEnd(start)]), face); shaped != nil {
+		// where the rune is on the screen, right to left runs are reordered
+		return shaped.caretX(len(string(in.runes[start:i]))).Round()
+	}
+	return advance(in.runes[start:i], face).Round()
+}
+
+// position of the rune i in the text, in pixels
+func (in *textInput) pos(i int, face font.Face, lineH int) image.Point {
+	row, _ := in.rowCol(i)
+	return image.Pt(in.lineX(i, face), row*lineH)
+}
+
+// the index in the line starting at start that is closest to x
+func (in *textInput) colAt(start, x int, face font.Face) int {
+	end := in.lineEnd(start)
+	if shaped := shape(string(in.runes[start:end]), face); shaped != nil {
+		// the closest caret, they aren't in order
+		best, bestD, offset := start, -1, 0
+		for i := start; i <= end; i++ {
+			d := shaped.caretX(offset).Round() - x
+			d = Max(d, -d)
+			if bestD < 0 || d < bestD {
+				best, bestD = i, d
+			}
+			if i < end {
+				offset += utf8.RuneLen(in.runes[i])
+			}
+		}
+		return best
+	}
+	var dot fixed.Int26_6
+	for i := start; i < end; i++ {
+		if i > start {
+			dot += face.Kern(in.runes[i-1], in.runes[i])
+		}
+		adv, _ := face.GlyphAdvance(in.runes[i])
+		if x < (dot + adv/2).Round() {
+			return i
+		}
+		dot += adv
+	}
+	return end
+}
+
+// the index closest to p, p is relative to the start of the text
+func (in *textInput) indexAt(p image.Point, face font.Face, lineH int) int {
+	starts := in.lineStarts()
+	row := 0
+	if p.Y > 0 {
+		row = Min(p.Y/lineH, len(starts)-1)
+	}
+	return in.colAt(starts[row], p.X, face)
+}
+
+// kerned like the atlas draws it
+func advance(runes []rune, face font.Face) fixed.Int26_6 {
+	var dot fixed.Int26_6
+	for i, r := range runes {
+		if i > 0 {
+			dot += face.Kern(runes[i-1], r)
+		}
+		adv, _ := face.GlyphAdvance(r)
+		dot += adv
+	}
+	return dot
+}
+
+func (in *textInput) render(size Size, inner image.Rectangle, label string, focused bool, theme *ButtonColorTheme, lineH int) image.Image {
+	face := theme.FontFace
+	img := image.NewRGBA(image.Rectangle{image.ZP, size})
+
+	bg := theme.BgUp
+	if focused {
+		bg = theme.BgHover
+	}
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.ZP, draw.Src)
+
+	// everything in the text is clipped to inner
+	dst := img.SubImage(inner).(*image.RGBA)
+	origin := inner.Min.Sub(in.scroll)
+
+	selColor := selectionColor(theme)
+	if start, end := in.selection(); start != end {
+		starts := in.lineStarts()
+		for row, ls := range starts {
+			le := in.lineEnd(ls)
+			if le < start || ls > end {
+				continue
+			}
+			a, b := Max(ls, start), Min(le, end)
+			var pieces [][2]int
+			if shaped := shape(string(in.runes[ls:le]), face); shaped != nil {
+				// right to left runs break the selection up
+				offset := len(string(in.runes[ls:a]))
+				for _, p := range shaped.ranges(offset, offset+len(string(in.runes[a:b]))) {
+					pieces = append(pieces, [2]int{p[0].Round(), p[1].Round()})
+				}
+			} else {
+				pieces = append(pieces, [2]int{in.lineX(a, face), in.lineX(b, face)})
+			}
+			if end > le {
+				// the selected '\n'
+				x := in.lineX(le, face)
+				pieces = append(pieces, [2]int{x, x + lineH/4})
+			}
+			for _, p := range pieces {
+				r := image.Rect(p[0], row*lineH, p[1], (row+1)*lineH).Add(origin)
+				draw.Draw(dst, r, image.NewUniform(selColor), image.ZP, draw.Src)
+			}
+		}
+	}
+
+	ascent := face.Metrics().Ascent
+	if len(in.runes) == 0 && !focused {
+		// the label as placeholder, half transparent
+		c := theme.Text
+		dot := fixed.P(origin.X, origin.Y).Add(fixed.Point26_6{Y: ascent})
+		atlas.drawString(dst, dot, visibleLabel(label), face, color.NRGBA{c.R, c.G, c.B, 128})
+	}
+	for row, ls := range in.lineStarts() {
+		dot := fixed.P(origin.X, origin.Y+row*lineH).Add(fixed.Point26_6{Y: ascent})
+		atlas.drawString(dst, dot, string(in.runes[ls:in.lineEnd(ls)]), face, theme.Text)
+	}
+
+	if focused {
+		p := in.pos(in.caret, face, lineH).Add(origin)
+		draw.Draw(dst, image.Rect(p.X, p.Y, p.X+2, p.Y+lineH), image.NewUniform(theme.Text), image.ZP, draw.Src)
+	}
+	decorate(img, ui_frame.style())
+	return img
+}
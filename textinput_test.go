@@ -0,0 +1,101 @@
+package tomato
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// a face that pulls V close to the A before it
+type kernedFace struct {
+	font.Face
+}
+
+func (f kernedFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	if r0 == 'A' && r1 == 'V' {
+		return -fixed.I(6)
+	}
+	return 0
+}
+
+// the caret goes where the kerned text is drawn
+func TestTextInputKerning(t *testing.T) {
+	face := kernedFace{Font("sans", 20)}
+	in := &textInput{runes: []rune("AVAV")}
+	for i := 0; i <= len(in.runes); i++ {
+		want := font.MeasureString(face, string(in.runes[:i])).Round()
+		if got := in.lineX(i, face); got != want {
+			t.Errorf("the caret %v is at %v, want %v", i, got, want)
+		}
+		if i > 0 && i < len(in.runes) {
+			if got := in.colAt(0, want, face); got != i {
+				t.Errorf("at %v the column is %v, want %v", want, got, i)
+			}
+		}
+	}
+}
+
+// typing into a text field that was clicked, moving, selecting and undoing
+func TestTextInputTyping(t *testing.T) {
+	testWindow(t, 300, 100)
+	text := ""
+	frame := func() bool {
+		Layout(0, Vertical, image.Rect(0, 0, 300, 100))
+		changed := TextInput("name", &text, nil)
+		DrawUi()
+		return changed
+	}
+	frame()
+	typeText("ignored")
+	if frame() || text != "" {
+		t.Fatalf("the field took the runes without the focus: %q", text)
+	}
+
+	moveTo(150, 10)
+	press(MouseLeft)
+	frame()
+	release(MouseLeft)
+	frame()
+	if !WantsKeyboard() {
+		t.Fatalf("the clicked field doesn't want the keyboard")
+	}
+
+	tests := []struct {
+		input func()
+		want  string
+	}{
+		{func() { typeText("hello") }, "hello"},
+		{func() { pressKey(Left, 0); pressKey(Left, 0); typeText("X") }, "helXlo"},
+		{func() { pressKey(Home, ModShift); typeText("A") }, "Alo"},
+		{func() { pressKey(Backspace, 0) }, "lo"},
+		{func() { pressKey(KeyZ, ModCtrl) }, "Alo"},
+		{func() { pressKey(KeyZ, ModCtrl) }, "helXlo"},
+		{func() { pressKey(KeyY, ModCtrl) }, "Alo"},
+		{func() { pressKey(KeyA, ModCtrl); pressKey(KeyC, ModCtrl); pressKey(End, 0); pressKey(KeyV, ModCtrl) }, "AloAlo"},
+		{func() { pressKey(Enter, 0) }, "AloAlo"}, // single line
+	}
+	for _, tt := range tests {
+		before := text
+		tt.input()
+		changed := frame()
+		if text != tt.want {
+			t.Errorf("the text is %q, want %q", text, tt.want)
+		}
+		if changed != (text != before) {
+			t.Errorf("%q to %q: TextInput returned %v", before, text, changed)
+		}
+	}
+
+	// a click somewhere else takes the focus away
+	moveTo(150, 90)
+	press(MouseLeft)
+	frame()
+	release(MouseLeft)
+	typeText("!")
+	frame()
+	if WantsKeyboard() || text != "AloAlo" {
+		t.Errorf("the field still has the focus, the text is %q", text)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+   Helpers to snapshot test tomato frames without a GPU.
+
+   A frame function queues its drawing (ToDraw, Layout, TextButton, ...),
+   Run composes it with the Software backend and Golden compares the result
+   against a png in testdata/:
+
+       func TestMenu(t *testing.T) {
+           img := tomatotest.Run(400, 300, func() {
+               tomato.Layout(0, tomato.Vertical, image.Rect(0, 0, 200, 300))
+               tomato.TextButton(0, "Open", nil)
+           })
+           tomatotest.Golden(t, "menu", img, 2)
+       }
+
+   Run `go test -tomato.update` to (re)write the goldens.
+   On a mismatch testdata/<name>.diff.png shows the differing pixels in red.
+   Without cgo or the X11 headers (CI, servers) add -tags headless.
+*/
+
+package tomatotest
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bbeni/tomato"
+)
+
+var update = flag.Bool("tomato.update", false, "rewrite the golden images of tomatotest.Golden")
+
+// where the goldens are stored, relative to the package under test
+var Dir = "testdata"
+
+// the window of Run, made the first time and resized after that
+var win *tomato.Window
+var sw *tomato.Software // draws only win
+
+// Runs frame on a headless window of the given size and returns a copy of the composed frame.
+// The Ui is set up again for every call, so frames don't share state.
+// All calls share one window, it becomes the current one.
+func Run(width, height int, frame func()) *image.RGBA {
+	if win == nil {
+		sw = &tomato.Software{}
+		w, err := tomato.NewWindowWithBackend(sw, tomato.Options{Width: width, Height: height})
+		if err != nil {
+			panic(err)
+		}
+		win = w
+	} else if win.Img.Bounds().Size() != image.Pt(width, height) {
+		sw.Resize(width, height)
+	}
+	win.MakeCurrent()
+	tomato.Clear()
+	tomato.SetupUi()
+
+	frame()
+	tomato.DrawUi()
+
+	img := image.NewRGBA(sw.Frame().Bounds())
+	draw.Draw(img, img.Bounds(), sw.Frame(), img.Bounds().Min, draw.Src)
+	return img
+}
+
+// Compares img against testdata/<name>.png. A pixel matches if no channel
+// differs by more than tolerance.
+func Golden(t testing.TB, name string, img image.Image, tolerance uint8) {
+	t.Helper()
+
+	path := filepath.Join(Dir, name+".png")
+	diffPath := filepath.Join(Dir, name+".diff.png")
+
+	if *update {
+		if err := writePng(path, img); err != nil {
+			t.Fatalf("tomatotest: could not write golden: %v", err)
+		}
+		os.Remove(diffPath)
+		return
+	}
+
+	want, err := readPng(path)
+	if err != nil {
+		t.Fatalf("tomatotest: could not read golden (run with -tomato.update to create it): %v", err)
+	}
+
+	diff, bad := Compare(img, want, tolerance)
+	if bad == 0 {
+		os.Remove(diffPath)
+		return
+	}
+
+	if err := writePng(diffPath, diff); err != nil {
+		t.Errorf("tomatotest: could not write diff: %v", err)
+	}
+	t.Errorf("tomatotest: %v differs from golden in %v pixels, see %v", name, bad, diffPath)
+}
+
+// Compares two images pixel by pixel. Returns an image of the differences
+// (mismatches red, matches a dark gray version of want) and how many pixels differ.
+// Images of different size are compared over the union of their bounds.
+func Compare(got, want image.Image, tolerance uint8) (*image.RGBA, int) {
+	bounds := got.Bounds().Union(want.Bounds())
+	diff := image.NewRGBA(bounds)
+	bad := 0
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Pt(x, y)
+			if !p.In(got.Bounds()) || !p.In(want.Bounds()) {
+				diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				bad++
+				continue
+			}
+
+			g := color.RGBAModel.Convert(got.At(x, y)).(color.RGBA)
+			w := color.RGBAModel.Convert(want.At(x, y)).(color.RGBA)
+
+			if channelDiff(g.R, w.R) > tolerance ||
+				channelDiff(g.G, w.G) > tolerance ||
+				channelDiff(g.B, w.B) > tolerance ||
+				channelDiff(g.A, w.A) > tolerance {
+				diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				bad++
+			} else {
+				gray := uint8((uint(w.R) + uint(w.G) + uint(w.B)) / 3 / 4)
+				diff.SetRGBA(x, y, color.RGBA{gray, gray, gray, 255})
+			}
+		}
+	}
+
+	return diff, bad
+}
+
+func channelDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func readPng(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func writePng(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,147 @@
+package tomatotest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/bbeni/tomato"
+)
+
+func buttons() {
+	tomato.Layout(0, tomato.Vertical, image.Rect(10, 10, 190, 110))
+	tomato.TextButton(0, "Open", nil)
+	tomato.TextButton(1, "Quit", nil)
+}
+
+func TestRunGolden(t *testing.T) {
+	Golden(t, "buttons", Run(200, 120, buttons), 2)
+}
+
+func TestRunReusesTheWindow(t *testing.T) {
+	first := Run(200, 120, buttons)
+	w := tomato.Current()
+
+	small := Run(80, 60, buttons)
+	if got := small.Bounds().Size(); got != image.Pt(80, 60) {
+		t.Errorf("Run(80, 60) gave a %v frame", got)
+	}
+	again := Run(200, 120, buttons)
+	if tomato.Current() != w {
+		t.Errorf("Run made another window")
+	}
+	if _, bad := Compare(again, first, 0); bad != 0 {
+		t.Errorf("the same frame after a resize differs in %v pixels", bad)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	fill := func(w, h int, c color.RGBA) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		return img
+	}
+	gray := color.RGBA{100, 100, 100, 255}
+	tests := []struct {
+		name      string
+		got, want image.Image
+		tolerance uint8
+		bad       int
+	}{
+		{"same", fill(4, 4, gray), fill(4, 4, gray), 0, 0},
+		{"within tolerance", fill(4, 4, color.RGBA{102, 98, 100, 255}), fill(4, 4, gray), 2, 0},
+		{"beyond tolerance", fill(4, 4, color.RGBA{103, 100, 100, 255}), fill(4, 4, gray), 2, 16},
+		{"alpha", fill(4, 4, color.RGBA{100, 100, 100, 0}), fill(4, 4, gray), 254, 16},
+		{"bigger", fill(4, 5, gray), fill(4, 4, gray), 0, 4},
+		{"smaller", fill(3, 4, gray), fill(4, 4, gray), 0, 4},
+	}
+	for _, tt := range tests {
+		diff, bad := Compare(tt.got, tt.want, tt.tolerance)
+		if bad != tt.bad {
+			t.Errorf("%v: %v pixels differ, want %v", tt.name, bad, tt.bad)
+		}
+		if size := diff.Bounds().Size(); size != tt.got.Bounds().Union(tt.want.Bounds()).Size() {
+			t.Errorf("%v: the diff is %v", tt.name, size)
+		}
+	}
+
+	got, want := fill(2, 1, gray), fill(2, 1, gray)
+	got.SetRGBA(1, 0, color.RGBA{255, 255, 255, 255})
+	diff, _ := Compare(got, want, 0)
+	if c := diff.RGBAAt(1, 0); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("the differing pixel is %v in the diff, want red", c)
+	}
+	if c := diff.RGBAAt(0, 0); c != (color.RGBA{25, 25, 25, 255}) {
+		t.Errorf("the matching pixel is %v in the diff, want dark gray", c)
+	}
+}
+
+// records the failures of Golden
+type fakeT struct {
+	testing.TB
+	errors, fatals []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Fatalf(format string, args ...any) {
+	f.fatals = append(f.fatals, fmt.Sprintf(format, args...))
+	runtime.Goexit()
+}
+
+// runs Golden on its own goroutine, Fatalf ends it
+func golden(name string, img image.Image) *fakeT {
+	f := &fakeT{}
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		Golden(f, name, img, 0)
+	}()
+	<-done
+	return f
+}
+
+func TestGolden(t *testing.T) {
+	defer func(dir string) { Dir = dir }(Dir)
+	Dir = t.TempDir()
+
+	img := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	img.SetRGBA(1, 1, color.RGBA{200, 0, 0, 255})
+
+	if f := golden("missing", img); len(f.fatals) != 1 {
+		t.Errorf("a missing golden gave %v %v", f.errors, f.fatals)
+	}
+
+	*update = true
+	f := golden("dot", img)
+	*update = false
+	if len(f.errors)+len(f.fatals) != 0 {
+		t.Fatalf("writing the golden failed: %v %v", f.errors, f.fatals)
+	}
+
+	if f := golden("dot", img); len(f.errors)+len(f.fatals) != 0 {
+		t.Errorf("the same image doesn't match its golden: %v %v", f.errors, f.fatals)
+	}
+	diffPath := filepath.Join(Dir, "dot.diff.png")
+	if _, err := os.Stat(diffPath); err == nil {
+		t.Errorf("a match wrote %v", diffPath)
+	}
+
+	other := image.NewRGBA(img.Bounds())
+	if f := golden("dot", other); len(f.errors) != 1 {
+		t.Errorf("a different image gave %v %v", f.errors, f.fatals)
+	}
+	if _, err := os.Stat(diffPath); err != nil {
+		t.Errorf("a mismatch didn't write the diff: %v", err)
+	}
+}
@@ -4,26 +4,28 @@
 
    Here is the button, layout and all Ui related stuff implemented.
 
-   We have layout (Vertical for now) that can hold buttons.
+   We have layouts (see layout.go) that hold the buttons.
    The layout can be placed anywhere.
 
-   the button function returns true once when it is clicked.
-   @Todo should we change this to return true when the button is down and let
-         the user handle the clicked logic?
+   the button function returns true once when it is clicked, that is the mouse
+   went down and up again on it (or Enter/Space while it has the focus).
+   TextButtonState tells more, like if it is held down or was double clicked.
 */
 
 package tomato
 
 import (
-	//"fmt"
+	"encoding/json"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
-	"golang.org/x/image/font/gofont/gomono"
 	"golang.org/x/image/math/fixed"
 )
 
@@ -33,154 +35,391 @@ const (
 	TEXT_SIZE     float64 = 24
 	BUTTON_HEIGHT float64 = 56
 	Y_MARGIN      int     = 4
-	MAX_BUTTONS   int     = 32
+	X_PADDING     int     = 12 // left and right of the text of buttons in horizontal layouts
 )
 
 type button struct {
-	Size     Size
-	DrwUp    draw.Image
-	DrwDown  draw.Image
-	DrwHover draw.Image
-}
-
-type layout struct {
-	Ori     Orientation
-	Place   image.Rectangle
-	Elems   [MAX_BUTTONS]button
-	NextPos image.Point
+	Size        Size
+	Text        string
+	Theme       ButtonColorTheme
+	Style       Style
+	DrwUp       draw.Image
+	DrwDown     draw.Image
+	DrwHover    draw.Image
+	DrwDisabled draw.Image
+
+	used bool // in this frame, the ones not used are evicted at the end of the frame
 }
 
 type Ui_Frame struct {
 	Layouts      []layout
-	Active       int // maps to active layout
-	DefaultTheme ButtonColorTheme
+	Active       int              // maps to active layout
+	stack        []int            // of active layouts, SubLayout() pushes, EndLayout() pops
+	ids          []ID             // scopes of PushID()
+	DefaultTheme ButtonColorTheme // the colors of the current Style
+	Style        Style            // see SetStyle
+	styles       []Style          // PushStyle() stack
+	defaultFont  font.Face
+	fonts        int // the fontsVersion of the faces of the theme
+
+	previousDown  bool // keeps track of the prevois MouseDownL state to detect clicks
+	previousDownR bool // and MouseDownR
+
+	disabled   int // BeginDisabled() - EndDisabled()
+	pressedR   ID  // the button the right mouse button went down on
+	lastClick  ID  // for double clicks
+	lastClickT time.Time
+
+	focus        ID              // the widget that gets the keyboard, 0 if none
+	focusVisible bool            // the focus came from the keyboard, draw the ring
+	order        []ID            // of the focusable widgets of this frame, for Tab
+	dragging     ID              // the widget the mouse went down on, until it is released
+	dragStart    image.Point     // mouse position when the drag started
+	dragValue    float64         // of the dragged number when the drag started
+	dragRect     image.Rectangle // of the dragged panel when the drag started
+	dragEdges    int             // of the resized panel
+	inputs       map[ID]*textInput
+
+	panels     map[int]*panel
+	panelZ     int // of the panel in front
+	panelStack []panelEntry
+	inPanel    *panel // the one that is made right now, nil outside of panels
+	hovered    *panel // the one in front under the mouse, nil if none
+
+	persistPath string                 // see PersistUi
+	restored    uiState                // loaded, but not given back yet
+	values      map[ID]persistedValue  // of the widgets of this frame, for SaveUi
+	gone        map[ID]json.RawMessage // the last values of the widgets that aren't there anymore
+
+	keyboard     []Ev        // KeyDown, KeyRepeat and RuneTyped since the last frame, for the focused widget
+	wheel        image.Point // MouScroll since the last frame, for the scroll panel under the mouse
+	tabs         int         // Tab - Shift+Tab since the last frame
+	keyboardLock sync.Mutex
 }
 
-type Orientation uint8
-
-const (
-	Vertical Orientation = iota
-	Horizontal
-)
-
 type ButtonColorTheme struct {
 	Text     color.RGBA
 	BgUp     color.RGBA
 	BgHover  color.RGBA
+	BgDown   color.RGBA // when pressed, BgHover is used if it is zero
 	FontFace font.Face
+	// of selected text, the one of the DefaultTheme is used if it is zero
+	Selection color.RGBA
+	// of the ring around the focused widget, the one of the DefaultTheme is used if it is zero
+	Focus color.RGBA
 	//Blink color.RGBA
 }
 
-var ui_frame Ui_Frame
+var ui_frame *Ui_Frame // of the current window
 
 func SetupUi() {
-
-	ui_frame = Ui_Frame{}
-	font, err := truetype.Parse(gomono.TTF)
-	if err != nil {
-		panic(err)
+	if ui_frame == nil {
+		panic("\ntomato ERROR: call tomato.Create(...) before SetupUi()\n")
 	}
 
-	fontFace := truetype.NewFace(font, &truetype.Options{
-		Size: TEXT_SIZE,
-	})
-
-	ui_frame.DefaultTheme = ButtonColorTheme{
-		Text:     color.RGBA{255, 250, 240, 255}, // Floral White
-		BgUp:     color.RGBA{36, 33, 36, 255},    // Raisin Black
-		BgHover:  color.RGBA{45, 45, 45, 255},
-		FontFace: fontFace,
-	}
+	*ui_frame = Ui_Frame{fonts: -1}
+	ui_frame.Style = DarkStyle()
+	ui_frame.syncTheme()
 	ui_frame.Layouts = make([]layout, 0)
-}
-
-func Layout(id int, orientation Orientation, place image.Rectangle) {
-	if id >= len(ui_frame.Layouts) {
-		if id != len(ui_frame.Layouts) {
-			panic("Need To call Layout(...) with ids starting from 0 in increaing manner.")
-		}
-		ui_frame.Layouts = append(ui_frame.Layouts, layout{
-			Ori:     orientation,
-			Place:   place,
-			NextPos: place.Min,
-		})
-	}
-	ui_frame.Active = id
+	ui_frame.inputs = make(map[ID]*textInput)
+	ui_frame.panels = make(map[int]*panel)
+	ui_frame.values = make(map[ID]persistedValue)
+	ui_frame.gone = make(map[ID]json.RawMessage)
 }
 
 // in current layout! delete the buttons for now
 func InvalidateElements() {
 	lay := &ui_frame.Layouts[ui_frame.Active]
-	for id := range MAX_BUTTONS {
-		lay.Elems[id] = button{}
-	}
+	clear(lay.Elems)
 }
 
-var previousDown bool // keeps track of the prevois MouseDownL state to detect clicks
+// What happened to a button in this frame
+type ButtonState struct {
+	Hovered       bool
+	Held          bool // the left mouse button went down on it and is still down
+	Clicked       bool // released on it after it was pressed on it, or Enter/Space with the focus
+	RightClicked  bool
+	DoubleClicked bool // the second click in DOUBLE_CLICK on the same button, Clicked is true too
+}
+
+const DOUBLE_CLICK = 400 * time.Millisecond
 
 // returns true if it has been clicked!
+// id only has to be unique in the layout (and the PushID scope)
 func TextButton(id int, text string, theme *ButtonColorTheme) bool { // use nil for default theme
+	return textButton(intID(id), text, theme).Clicked
+}
+
+func TextButtonState(id int, text string, theme *ButtonColorTheme) ButtonState { // use nil for default theme
+	return textButton(intID(id), text, theme)
+}
+
+// Like TextButton, but the id comes from the label.
+// Use "Text##something" to tell apart buttons with the same text, only "Text" is shown.
+func LabelButton(label string, theme *ButtonColorTheme) bool { // use nil for default theme
+	return textButton(GetID(label), visibleLabel(label), theme).Clicked
+}
+
+func LabelButtonState(label string, theme *ButtonColorTheme) ButtonState { // use nil for default theme
+	return textButton(GetID(label), visibleLabel(label), theme)
+}
+
+// The buttons until EndDisabled() are greyed out and can't be clicked.
+// @Todo the other widgets
+func BeginDisabled() {
+	ui_frame.disabled++
+}
+
+func EndDisabled() {
+	if ui_frame.disabled == 0 {
+		panic("\ntomato ERROR: EndDisabled() without BeginDisabled()\n")
+	}
+	ui_frame.disabled--
+}
+
+func textButton(id ID, text string, theme *ButtonColorTheme) ButtonState {
 	if len(ui_frame.Layouts) == 0 {
 		panic("\ntomato ERROR: call ui.Layout(0, ui.Vertical, image.Rect(0,0,100,100)) at least before button!\n")
 	}
 
-	// @Todo make id independent of MAX_BUTTONS
-	if id >= MAX_BUTTONS || id < 0 {
-		panic("id must be from 0 to MAX_BUTTONS-1")
+	lay := &ui_frame.Layouts[ui_frame.Active]
+
+	if theme == nil {
+		theme = &ui_frame.DefaultTheme
 	}
+	st := ui_frame.style()
 
-	lay := &ui_frame.Layouts[ui_frame.Active]
+	want := Size{0, widgetHeight(theme.FontFace)}
+	if lay.Ori == Horizontal {
+		want.X = measureString(theme.FontFace, text).Ceil() + 2*st.Padding
+	}
+	target := lay.next(want)
 
-	// create if it doesn't exist yet
-	if lay.Elems[id].DrwUp == nil {
-		size := Size{lay.Place.Dx(), int(math.Ceil(BUTTON_HEIGHT))}
+	// create if it doesn't exist yet, or it looks different now
+	b, ok := lay.Elems[id]
+	if !ok || b.Size != target.Size() || b.Text != text || b.Theme != *theme || b.Style != *st {
+		size := target.Size()
 		rect := image.Rectangle{image.Pt(0, 0), size}
 
-		if theme == nil {
-			theme = &ui_frame.DefaultTheme
+		u, h, d, dis := button_render(text, theme, st, rect)
+
+		b = &button{
+			Size:        size,
+			Text:        text,
+			Theme:       *theme,
+			Style:       *st,
+			DrwUp:       u,
+			DrwDown:     d,
+			DrwHover:    h,
+			DrwDisabled: dis,
 		}
-		u, h := button_render(text, theme, rect)
+		lay.Elems[id] = b
+	}
+	b.used = true
+
+	if ui_frame.disabled > 0 {
+		drawWidget(target, b.DrwDisabled)
+		return ButtonState{}
+	}
+
+	state := ui_frame.buttonBehavior(id, target)
+
+	switch {
+	case state.Held && state.Hovered:
+		drawWidget(target, b.DrwDown)
+	case state.Hovered:
+		drawWidget(target, b.DrwHover)
+	default:
+		drawWidget(target, b.DrwUp)
+	}
+	if ui_frame.focus == id {
+		drawFocusRing(target, theme)
+	}
+	return state
+}
+
+// The clicking part of buttons. A click is the mouse going down and up again on the button,
+// dragging off it before the release cancels the click.
+// @Todo a click faster than a frame is missed, the input is only looked at once per frame
+func (frame *Ui_Frame) buttonBehavior(id ID, target image.Rectangle) ButtonState {
+	var state ButtonState
+	in := mouseIn(target)
+	focused := frame.focusable(id, target)
 
-		ui_frame.Layouts[ui_frame.Active].Elems[id] = button{
-			Size:     size,
-			DrwUp:    u,
-			DrwDown:  h,
-			DrwHover: h,
+	if clickedIn(target) {
+		frame.dragging = id
+	}
+	if frame.dragging == id {
+		if current.MouseDownL {
+			state.Held = true
+		} else {
+			frame.dragging = 0
+			state.Clicked = in
 		}
 	}
+	state.Hovered = in && (frame.dragging == 0 || frame.dragging == id)
 
-	b := &ui_frame.Layouts[ui_frame.Active].Elems[id]
-	zp := lay.NextPos
-	mp := zp.Add(b.Size)
-	target := image.Rectangle{zp, mp}
-	mouse := image.Pt(MouseX, MouseY)
+	if current.MouseDownR && !frame.previousDownR && in {
+		frame.pressedR = id
+	}
+	if frame.pressedR == id && !current.MouseDownR {
+		frame.pressedR = 0
+		state.RightClicked = in
+	}
 
-	if lay.Ori == Vertical {
-		if mouse.In(target) {
-			ToDraw(target, b.DrwHover)
+	// or pressed it with the keyboard
+	if focused && frame.takeKey(Enter, KpEnter, Space) {
+		state.Clicked = true
+	}
+
+	if state.Clicked {
+		now := time.Now()
+		if frame.lastClick == id && now.Sub(frame.lastClickT) < DOUBLE_CLICK {
+			state.DoubleClicked = true
+			frame.lastClick = 0
 		} else {
-			ToDraw(target, b.DrwUp)
+			frame.lastClick = id
+			frame.lastClickT = now
 		}
-		lay.NextPos = zp.Add(image.Pt(0, b.Size.Y+Y_MARGIN))
+	}
+	return state
+}
 
-	} else {
-		panic("not implemented!")
+// the mouse is over the visible part of target, and no panel is in front of it
+func mouseIn(target image.Rectangle) bool {
+	if ui_frame.hovered != ui_frame.inPanel {
+		return false
 	}
+	return image.Pt(current.MouseX, current.MouseY).In(target.Intersect(current.ClipRect()))
+}
+
+// Identifies a widget, see GetID()
+type ID uint64
 
-	// We know we clicked and are inside this button
-	if MouseDownL && previousDown != MouseDownL && mouse.In(target) {
-		return true
+// fnv-1a 64 offset basis
+const idSeed ID = 14695981039346656037
+
+// continues the fnv-1a hash seed with s
+func hashID(seed ID, s string) ID {
+	h := seed
+	for i := 0; i < len(s); i++ {
+		h ^= ID(s[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+func (frame *Ui_Frame) idScope() ID {
+	if len(frame.ids) == 0 {
+		return idSeed
+	}
+	return frame.ids[len(frame.ids)-1]
+}
+
+// The ID of a widget with this label in the current PushID scope.
+// Everything after "##" is part of the ID but not shown.
+func GetID(label string) ID {
+	return hashID(ui_frame.idScope(), label)
+}
+
+// The ID of the widgets made with an int (TextButton, Checkbox, ...) in the
+// current PushID scope. The ints only have to be unique in their layout, so
+// the active layout is part of the ID. Without it TextButton(0, ...) in two
+// layouts would be the same widget for the focus, Tab and the persisted values.
+func intID(id int) ID {
+	return hashID(ui_frame.idScope(), strconv.Itoa(ui_frame.Active)+"#"+strconv.Itoa(id))
+}
+
+// what is shown of a label, see GetID
+func visibleLabel(label string) string {
+	if i := strings.Index(label, "##"); i >= 0 {
+		return label[:i]
+	}
+	return label
+}
+
+// Makes the IDs of the following widgets unique to key (a string, int, ...),
+// until PopID(). Useful for widgets made in a loop.
+func PushID(key any) {
+	ui_frame.ids = append(ui_frame.ids, hashID(ui_frame.idScope(), fmt.Sprint(key)))
+}
+
+func PopID() {
+	if len(ui_frame.ids) == 0 {
+		panic("\ntomato ERROR: PopID() without PushID(...)\n")
 	}
+	ui_frame.ids = ui_frame.ids[:len(ui_frame.ids)-1]
+}
+
+// called by Inject, for the keys and the mouse wheel
+func (frame *Ui_Frame) pushKeyboard(ev Ev) {
+	frame.keyboardLock.Lock()
+	if ev.Kind == MouScroll {
+		frame.wheel = frame.wheel.Add(ev.Point)
+	} else if ev.Kind != RuneTyped && ev.Key == Tab {
+		if ev.Mods&ModShift != 0 {
+			frame.tabs--
+		} else {
+			frame.tabs++
+		}
+	} else {
+		frame.keyboard = append(frame.keyboard, ev)
+	}
+	frame.keyboardLock.Unlock()
+}
+
+func (frame *Ui_Frame) takeKeyboard() []Ev {
+	frame.keyboardLock.Lock()
+	evs := frame.keyboard
+	frame.keyboard = nil
+	frame.keyboardLock.Unlock()
+	return evs
+}
+
+func (frame *Ui_Frame) takeWheel() image.Point {
+	frame.keyboardLock.Lock()
+	wheel := frame.wheel
+	frame.wheel = image.Point{}
+	frame.keyboardLock.Unlock()
+	return wheel
+}
 
-	return false
+// True if a text field has the focus and uses the keys,
+// check it before handling shortcuts from Events()
+func WantsKeyboard() bool {
+	if ui_frame == nil || ui_frame.focus == 0 {
+		return false
+	}
+	_, ok := ui_frame.inputs[ui_frame.focus]
+	return ok
 }
 
 func DrawUi() {
 	// reset all layout next positions to their origin
 	for i := range ui_frame.Layouts {
-		ui_frame.Layouts[i].NextPos = ui_frame.Layouts[i].Place.Min
+		ui_frame.Layouts[i].endFrame()
+	}
+	ui_frame.previousDown = current.MouseDownL
+	ui_frame.previousDownR = current.MouseDownR
+	// released somewhere nobody looked
+	if !current.MouseDownL {
+		ui_frame.dragging = 0
+	}
+	if !current.MouseDownR {
+		ui_frame.pressedR = 0
 	}
-	previousDown = MouseDownL
+	ui_frame.ids = ui_frame.ids[:0]
+	// after LoadFont the next frame gets the new faces
+	if changed, _ := fontsChanged(ui_frame.fonts); changed || len(ui_frame.styles) > 0 {
+		ui_frame.styles = ui_frame.styles[:0]
+		ui_frame.syncTheme()
+	}
+	ui_frame.endTextInputs()
+	ui_frame.endValues()
+	ui_frame.endFocus()
+	ui_frame.endPanels()
+	ui_frame.takeKeyboard() // nobody wanted them
+	ui_frame.takeWheel()
 
 	// @Todo should it call it?
 	Draw()
@@ -194,7 +433,7 @@ func RenderText(text string, textColor, btnColor color.RGBA, fontFace font.Face)
 		Dot:  fixed.P(0, 0),
 	}
 
-	b26_6, _ := drawer.BoundString(text)
+	b26_6, _ := boundString(fontFace, text)
 	bounds := image.Rect(
 		b26_6.Min.X.Floor(),
 		b26_6.Min.Y.Floor(),
@@ -205,95 +444,41 @@ func RenderText(text string, textColor, btnColor color.RGBA, fontFace font.Face)
 	drawer.Dst = image.NewRGBA(bounds)
 	btnUpUniform := image.NewUniform(btnColor)
 	draw.Draw(drawer.Dst, bounds, btnUpUniform, image.ZP, draw.Src)
-	drawer.DrawString(text)
+	atlas.drawString(drawer.Dst, drawer.Dot, text, fontFace, textColor)
 	return drawer.Dst
 }
 
+// Wraps text at maxWidth, see LayoutText for more options
 func RenderTextMulti(text string, textColor, bgColor color.RGBA, fontFace font.Face, maxWidth int) draw.Image {
-
-	drawer := &font.Drawer{
-		Src:  &image.Uniform{textColor},
-		Face: fontFace,
-		Dot:  fixed.P(0, 0),
-	}
-
-	lines := make([]string, 0)
-
-	j := 0
-	i := 0
-	for i = 0; i < len(text)-1; i++ {
-		b26_6, _ := drawer.BoundString(text[j : i+1])
-		if b26_6.Max.X.Ceil()-b26_6.Min.X.Floor() > maxWidth {
-			lines = append(lines, text[j:i])
-			j = i
-		}
-	}
-
-	if i != j {
-		lines = append(lines, text[j:i])
-	}
-
-	maxW := 0
-	lineH := 0
-	for _, line := range lines {
-		b26_6, _ := drawer.BoundString(line)
-		bounds := image.Rect(
-			b26_6.Min.X.Floor(),
-			b26_6.Min.Y.Floor(),
-			b26_6.Max.X.Ceil(),
-			b26_6.Max.Y.Ceil(),
-		)
-		if bounds.Dx() > maxW {
-			maxW = bounds.Dx()
-		}
-		if bounds.Dy() > lineH {
-			lineH = bounds.Dy()
-		}
-	}
-
-	bounds := image.Rect(0, 0, maxW, (len(lines)+1)*lineH)
-	result := image.NewRGBA(bounds)
-	bgUniform := image.NewUniform(bgColor)
-	draw.Draw(result, bounds, bgUniform, image.ZP, draw.Src)
-
-	for i, line := range lines {
-		tImage := RenderText(line, textColor, bgColor, fontFace)
-		draw.Draw(result, bounds, tImage, bounds.Min.Sub(image.Pt(0, lineH*(i+1))), draw.Src)
-	}
-	return result
+	return RenderTextOptions(text, textColor, bgColor, fontFace, TextOptions{MaxWidth: maxWidth})
 }
 
-func button_render(text string, colorTheme *ButtonColorTheme, r image.Rectangle) (draw.Image, draw.Image) {
-	var textImageUp image.Image
-	var textImageHover image.Image
-	{
-		textImageUp = RenderText(text, colorTheme.Text, colorTheme.BgUp, colorTheme.FontFace)
-		textImageHover = RenderText(text, colorTheme.Text, colorTheme.BgHover, colorTheme.FontFace)
+// returns the up, hover, down and disabled images
+func button_render(text string, colorTheme *ButtonColorTheme, style *Style, r image.Rectangle) (draw.Image, draw.Image, draw.Image, draw.Image) {
+	bgDown := colorTheme.BgDown
+	if bgDown.A == 0 {
+		bgDown = colorTheme.BgHover
 	}
 
-	redraw := func(hover bool) draw.Image {
+	redraw := func(textColor, bgColor color.RGBA) draw.Image {
 		img := image.NewRGBA(r)
-		var textImage image.Image
-		var buttonBg image.Image
-		if hover {
-			buttonBg = image.NewUniform(colorTheme.BgHover)
-			textImage = textImageHover
-		} else {
-			buttonBg = image.NewUniform(colorTheme.BgUp)
-			textImage = textImageUp
-		}
+		textImage := RenderText(text, textColor, bgColor, colorTheme.FontFace)
+		buttonBg := image.NewUniform(bgColor)
 		draw.Draw(img, r, buttonBg, image.ZP, draw.Src)
 		textRect := r
 		textRect.Min.Y += textRect.Dy()/2 - textImage.Bounds().Dy()/2
 		textRect.Min.X += textRect.Dx()/2 - textImage.Bounds().Dx()/2
 
 		draw.Draw(img, textRect, textImage, textImage.Bounds().Min, draw.Src)
+		decorate(img, style)
 		return img
 	}
 
-	normalImg := redraw(false)
-	hoveredImg := redraw(true)
-	return normalImg, hoveredImg
+	normalImg := redraw(colorTheme.Text, colorTheme.BgUp)
+	hoveredImg := redraw(colorTheme.Text, colorTheme.BgHover)
+	downImg := redraw(colorTheme.Text, bgDown)
+	disabledImg := redraw(style.TextDisabled, style.BgDisabled)
+	return normalImg, hoveredImg, downImg, disabledImg
 }
 
 func Min(a, b int) int {
@@ -0,0 +1,134 @@
+package tomato
+
+import (
+	"image"
+	"testing"
+)
+
+// clicks, double and right clicks of a button, only pressed and released on it
+func TestButtonBehavior(t *testing.T) {
+	in := func() { moveTo(50, 10) }
+	out := func() { moveTo(50, 90) }
+
+	tests := []struct {
+		name     string
+		steps    []func() // a frame after every one
+		disabled bool
+		want     ButtonState // any of the frames
+	}{
+		{"click", []func(){in, func() { press(MouseLeft) }, func() { release(MouseLeft) }}, false, ButtonState{Clicked: true}},
+		{"press inside, release outside", []func(){in, func() { press(MouseLeft) }, out, func() { release(MouseLeft) }}, false, ButtonState{}},
+		{"press outside, release inside", []func(){out, func() { press(MouseLeft) }, in, func() { release(MouseLeft) }}, false, ButtonState{}},
+		{"double click", []func(){in, func() { press(MouseLeft) }, func() { release(MouseLeft) }, func() { press(MouseLeft) }, func() { release(MouseLeft) }}, false, ButtonState{Clicked: true, DoubleClicked: true}},
+		{"right click", []func(){in, func() { press(MouseRight) }, func() { release(MouseRight) }}, false, ButtonState{RightClicked: true}},
+		{"right press inside, release outside", []func(){in, func() { press(MouseRight) }, out, func() { release(MouseRight) }}, false, ButtonState{}},
+		{"disabled", []func(){in, func() { press(MouseLeft) }, func() { release(MouseLeft) }}, true, ButtonState{}},
+		{"Enter with the focus", []func(){in, func() { press(MouseLeft) }, out, func() { release(MouseLeft) }, func() { pressKey(Enter, 0) }}, false, ButtonState{Clicked: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testWindow(t, 100, 100)
+			frame := func() ButtonState {
+				Layout(0, Vertical, image.Rect(0, 0, 100, 100))
+				if tt.disabled {
+					BeginDisabled()
+					defer EndDisabled()
+				}
+				state := TextButtonState(0, "button", nil)
+				DrawUi()
+				return state
+			}
+			out()
+			if frame().Hovered {
+				t.Fatalf("the button is hovered without the mouse on it")
+			}
+
+			var got ButtonState
+			for _, step := range tt.steps {
+				step()
+				state := frame()
+				got.Clicked = got.Clicked || state.Clicked
+				got.RightClicked = got.RightClicked || state.RightClicked
+				got.DoubleClicked = got.DoubleClicked || state.DoubleClicked
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// a checkbox changes when the click is released on it, not when it is pressed
+func TestCheckboxOnRelease(t *testing.T) {
+	testWindow(t, 100, 100)
+	checked := false
+	frame := func() {
+		Layout(0, Vertical, image.Rect(0, 0, 100, 100))
+		Checkbox(0, "check", &checked, nil)
+		DrawUi()
+	}
+	frame()
+
+	moveTo(50, 10)
+	press(MouseLeft)
+	frame()
+	if checked {
+		t.Errorf("the checkbox changed when it was pressed")
+	}
+	release(MouseLeft)
+	frame()
+	if !checked {
+		t.Errorf("the checkbox didn't change with the click")
+	}
+
+	press(MouseLeft)
+	frame()
+	moveTo(50, 90)
+	release(MouseLeft)
+	frame()
+	if !checked {
+		t.Errorf("the checkbox changed after the mouse went off it")
+	}
+}
+
+// buttons with the same label are told apart by PushID and "##"
+func TestIDsOfSameLabels(t *testing.T) {
+	testWindow(t, 100, 300)
+	frame := func() (states []ButtonState) {
+		Layout(0, Vertical, image.Rect(0, 0, 100, 300))
+		for i := 0; i < 3; i++ {
+			PushID(i)
+			states = append(states, LabelButtonState("Delete", nil))
+			PopID()
+		}
+		states = append(states, LabelButtonState("Save##a", nil), LabelButtonState("Save##b", nil))
+		DrawUi()
+		return states
+	}
+
+	// the mouse over each button in turn, only that one reacts
+	y := 0
+	for i := range frame() {
+		for ; y < 300; y++ {
+			moveTo(50, y)
+			if frame()[i].Hovered {
+				break
+			}
+		}
+		press(MouseLeft)
+		frame()
+		release(MouseLeft)
+		for j, state := range frame() {
+			if state.Clicked != (i == j) {
+				t.Errorf("clicking button %v clicked button %v: %v", i, j, state.Clicked)
+			}
+		}
+		// on to the next one
+		for ; y < 300 && frame()[i].Hovered; y++ {
+			moveTo(50, y)
+		}
+	}
+	if y >= 300 {
+		t.Errorf("not every button was found")
+	}
+}
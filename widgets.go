@@ -0,0 +1,341 @@
+/*
+   The small input widgets, they all take a cell of the current layout
+   like TextButton and return true when they changed the value:
+
+       tomato.Checkbox(0, "Wireframe", &wireframe, nil)
+       tomato.RadioGroup(1, []string{"Low", "Medium", "High"}, &quality, nil)
+       tomato.SliderFloat(2, "Volume", &volume, 0, 1, nil)
+       tomato.SliderInt(3, "Samples", &samples, 1, 16, nil)
+       tomato.DragFloat(4, "X", &x, 0.1, 0, 0, nil) // min == max: no limits
+       tomato.DragInt(5, "Count", &count, 0.2, 0, 100, nil)
+
+   Checkboxes and radios change when the click is released on them, like
+   buttons. Sliders jump to where they are clicked, drag numbers change by
+   speed per pixel the mouse is dragged. With the focus (see focus.go)
+   Space/Enter toggles checkboxes and radios, Left/Right step sliders and
+   drag numbers, Home/End go to min and max.
+*/
+
+package tomato
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const SLIDER_WIDTH int = 200 // in horizontal layouts
+
+func Checkbox(id int, label string, value *bool, theme *ButtonColorTheme) bool { // use nil for default theme
+	theme = themeOrDefault(theme)
+	box, pad := boxSize(theme), ui_frame.style().Padding
+	target := nextWidget("Checkbox", label, box+pad, theme)
+	wid := intID(id)
+	persistValue(wid, value)
+
+	// clicked on the release, like a button
+	changed := ui_frame.buttonBehavior(wid, target).Clicked
+	focused := ui_frame.focus == wid
+	if changed {
+		*value = !*value
+	}
+
+	img := widgetBg(target, theme)
+	r := image.Rect(pad, (target.Dy()-box)/2, pad+box, (target.Dy()+box)/2)
+	strokeRect(img, r, theme.Text)
+	if *value {
+		fillRect(img, r.Inset(box/4), theme.Text)
+	}
+	drawLabel(img, label, r.Max.X+pad, theme)
+
+	decorate(img, ui_frame.style())
+	drawWidget(target, img)
+	if focused {
+		drawFocusRing(target, theme)
+	}
+	return changed
+}
+
+// One radio button per option, selected is the index of the selected one
+func RadioGroup(id int, options []string, selected *int, theme *ButtonColorTheme) bool { // use nil for default theme
+	theme = themeOrDefault(theme)
+	box, pad := boxSize(theme), ui_frame.style().Padding
+	group := intID(id)
+	persistValue(group, selected)
+
+	changed := false
+	for i, option := range options {
+		target := nextWidget("RadioGroup", option, box+pad, theme)
+		wid := hashID(group, "#"+strconv.Itoa(i))
+
+		clicked := ui_frame.buttonBehavior(wid, target).Clicked
+		focused := ui_frame.focus == wid
+		if clicked && *selected != i {
+			*selected = i
+			changed = true
+		}
+
+		img := widgetBg(target, theme)
+		r := image.Rect(pad, (target.Dy()-box)/2, pad+box, (target.Dy()+box)/2)
+		strokeCircle(img, r, theme.Text)
+		if *selected == i {
+			fillCircle(img, r.Inset(box/4), theme.Text)
+		}
+		drawLabel(img, option, r.Max.X+pad, theme)
+
+		decorate(img, ui_frame.style())
+		drawWidget(target, img)
+		if focused {
+			drawFocusRing(target, theme)
+		}
+	}
+	return changed
+}
+
+func SliderFloat(id int, label string, value *float64, min, max float64, theme *ButtonColorTheme) bool { // use nil for default theme
+	wid := intID(id)
+	persistValue(wid, value)
+	return slider(wid, label, value, min, max, (max-min)/100, formatFloat, theme)
+}
+
+func SliderInt(id int, label string, value *int, min, max int, theme *ButtonColorTheme) bool { // use nil for default theme
+	wid := intID(id)
+	persistValue(wid, value)
+	old := *value
+	v := float64(*value)
+	slider(wid, label, &v, float64(min), float64(max), 1, formatInt, theme)
+	*value = int(math.Round(v))
+	return *value != old
+}
+
+// min == max means no limits
+func DragFloat(id int, label string, value *float64, speed, min, max float64, theme *ButtonColorTheme) bool { // use nil for default theme
+	wid := intID(id)
+	persistValue(wid, value)
+	return dragNumber(wid, label, value, speed, min, max, speed, formatFloat, theme)
+}
+
+// min == max means no limits
+func DragInt(id int, label string, value *int, speed float64, min, max int, theme *ButtonColorTheme) bool { // use nil for default theme
+	wid := intID(id)
+	persistValue(wid, value)
+	old := *value
+	v := float64(*value)
+	dragNumber(wid, label, &v, speed, float64(min), float64(max), 1, formatInt, theme)
+	*value = int(math.Round(v))
+	return *value != old
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func formatInt(v float64) string {
+	return strconv.Itoa(int(math.Round(v)))
+}
+
+func slider(id ID, label string, value *float64, min, max, step float64, format func(float64) string, theme *ButtonColorTheme) bool {
+	theme = themeOrDefault(theme)
+	target := nextWidget("Slider", label, SLIDER_WIDTH, theme)
+
+	old := *value
+	focused := ui_frame.focusable(id, target)
+	if ui_frame.drag(id, target) {
+		x := float64(current.MouseX-target.Min.X) / float64(Max(target.Dx(), 1))
+		*value = min + x*(max-min)
+	}
+	if focused {
+		*value = stepByKeys(*value, step, min, max)
+	}
+	*value = clampValue(*value, min, max)
+
+	img := widgetBg(target, theme)
+	if max > min {
+		filled := img.Bounds()
+		filled.Max.X = int(float64(filled.Dx()) * (*value - min) / (max - min))
+		fillRect(img, filled, selectionColor(theme))
+	}
+	drawLabelCentered(img, label+": "+format(*value), theme)
+
+	decorate(img, ui_frame.style())
+	drawWidget(target, img)
+	if focused {
+		drawFocusRing(target, theme)
+	}
+	return *value != old
+}
+
+func dragNumber(id ID, label string, value *float64, speed, min, max, step float64, format func(float64) string, theme *ButtonColorTheme) bool {
+	theme = themeOrDefault(theme)
+	target := nextWidget("DragNumber", label, SLIDER_WIDTH, theme)
+
+	old := *value
+	focused := ui_frame.focusable(id, target)
+	if clickedIn(target) {
+		ui_frame.dragStart = image.Pt(current.MouseX, current.MouseY)
+		ui_frame.dragValue = *value
+	}
+	if ui_frame.drag(id, target) {
+		*value = ui_frame.dragValue + float64(current.MouseX-ui_frame.dragStart.X)*speed
+	}
+	if focused {
+		*value = stepByKeys(*value, step, min, max)
+	}
+	*value = clampValue(*value, min, max)
+
+	img := widgetBg(target, theme)
+	drawLabelCentered(img, label+": "+format(*value), theme)
+
+	decorate(img, ui_frame.style())
+	drawWidget(target, img)
+	if focused {
+		drawFocusRing(target, theme)
+	}
+	return *value != old
+}
+
+// Left/Right and Home/End of the focused widget
+func stepByKeys(v, step, min, max float64) float64 {
+	for ui_frame.takeKeyRepeating(Left) {
+		v -= step
+	}
+	for ui_frame.takeKeyRepeating(Right) {
+		v += step
+	}
+	if min < max {
+		if ui_frame.takeKey(Home) {
+			v = min
+		}
+		if ui_frame.takeKey(End) {
+			v = max
+		}
+	}
+	return v
+}
+
+// min == max means no limits
+func clampValue(v, min, max float64) float64 {
+	if min >= max {
+		return v
+	}
+	return math.Max(min, math.Min(v, max))
+}
+
+// true while id is dragged with the mouse, it starts with a click into target
+func (frame *Ui_Frame) drag(id ID, target image.Rectangle) bool {
+	if clickedIn(target) {
+		frame.dragging = id
+	}
+	if frame.dragging != id {
+		return false
+	}
+	if !current.MouseDownL {
+		frame.dragging = 0
+		return false
+	}
+	return true
+}
+
+func themeOrDefault(theme *ButtonColorTheme) *ButtonColorTheme {
+	if theme == nil {
+		return &ui_frame.DefaultTheme
+	}
+	return theme
+}
+
+func selectionColor(theme *ButtonColorTheme) color.RGBA {
+	if theme.Selection.A == 0 {
+		return ui_frame.DefaultTheme.Selection
+	}
+	return theme.Selection
+}
+
+// the cell for a widget as high as a button, text and extra pixels are for the width in horizontal layouts
+func nextWidget(what, text string, extra int, theme *ButtonColorTheme) image.Rectangle {
+	if len(ui_frame.Layouts) == 0 {
+		panic("\ntomato ERROR: call ui.Layout(0, ui.Vertical, image.Rect(0,0,100,100)) at least before " + what + "!\n")
+	}
+	lay := &ui_frame.Layouts[ui_frame.Active]
+	want := Size{0, widgetHeight(theme.FontFace)}
+	if lay.Ori == Horizontal {
+		want.X = measureString(theme.FontFace, text).Ceil() + 2*ui_frame.style().Padding + extra
+	}
+	return lay.next(want)
+}
+
+// BUTTON_HEIGHT, or more for big fonts
+func widgetHeight(face font.Face) int {
+	return Max(int(math.Ceil(BUTTON_HEIGHT)), face.Metrics().Height.Ceil()+2*Y_MARGIN)
+}
+
+func clickedIn(target image.Rectangle) bool {
+	return current.MouseDownL && !ui_frame.previousDown && mouseIn(target)
+}
+
+// the size of the box of checkboxes and radios
+func boxSize(theme *ButtonColorTheme) int {
+	return theme.FontFace.Metrics().Height.Ceil() * 3 / 4
+}
+
+// @Speed the widgets are rendered again every frame
+func widgetBg(target image.Rectangle, theme *ButtonColorTheme) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{image.ZP, target.Size()})
+	bg := theme.BgUp
+	if mouseIn(target) {
+		bg = theme.BgHover
+	}
+	fillRect(img, img.Bounds(), bg)
+	return img
+}
+
+func fillRect(img draw.Image, r image.Rectangle, c color.RGBA) {
+	draw.Draw(img, r, image.NewUniform(c), image.ZP, draw.Src)
+}
+
+func strokeRect(img draw.Image, r image.Rectangle, c color.RGBA) {
+	w := 2
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+w), c)
+	fillRect(img, image.Rect(r.Min.X, r.Max.Y-w, r.Max.X, r.Max.Y), c)
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+w, r.Max.Y), c)
+	fillRect(img, image.Rect(r.Max.X-w, r.Min.Y, r.Max.X, r.Max.Y), c)
+}
+
+// the circle in r, from radius inner to the border of r
+func circle(img *image.RGBA, r image.Rectangle, inner float64, c color.RGBA) {
+	radius := float64(r.Dx()) / 2
+	cx := float64(r.Min.X) + radius
+	cy := float64(r.Min.Y) + float64(r.Dy())/2
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			d := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			if d <= radius && d >= inner {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+func fillCircle(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	circle(img, r, 0, c)
+}
+
+func strokeCircle(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	circle(img, r, float64(r.Dx())/2-2, c)
+}
+
+// text vertically centered, starting at x
+func drawLabel(img *image.RGBA, text string, x int, theme *ButtonColorTheme) {
+	m := theme.FontFace.Metrics()
+	y := (fixed.I(img.Bounds().Dy()) + m.Ascent - m.Descent) / 2
+	atlas.drawString(img, fixed.Point26_6{X: fixed.I(x), Y: y}, text, theme.FontFace, theme.Text)
+}
+
+func drawLabelCentered(img *image.RGBA, text string, theme *ButtonColorTheme) {
+	w := measureString(theme.FontFace, text).Ceil()
+	drawLabel(img, text, (img.Bounds().Dx()-w)/2, theme)
+}
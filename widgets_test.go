@@ -0,0 +1,134 @@
+package tomato
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// presses and releases the mouse at x, y, with a frame after each
+func clickAt(x, y int, frame func()) {
+	moveTo(x, y)
+	press(MouseLeft)
+	frame()
+	release(MouseLeft)
+	frame()
+}
+
+func TestCheckboxAndRadio(t *testing.T) {
+	testWindow(t, 300, 100)
+	checked, selected := false, 0
+	changed := [2]bool{}
+	frame := func() {
+		Layout(0, Vertical, image.Rect(0, 0, 300, 100))
+		changed[0] = Checkbox(0, "check", &checked, nil)
+		SubLayout(1, Grid, Size{})
+		StyleLayout(LayoutStyle{Columns: 3})
+		changed[1] = RadioGroup(0, []string{"a", "b", "c"}, &selected, nil)
+		EndLayout()
+		DrawUi()
+	}
+	frame()
+	y := widgetHeight(ui_frame.DefaultTheme.FontFace)
+
+	clickAt(150, y/2, frame)
+	if !checked {
+		t.Errorf("the click didn't check the checkbox")
+	}
+	clickAt(150, y/2, frame)
+	if checked {
+		t.Errorf("the second click didn't uncheck the checkbox")
+	}
+
+	for _, i := range []int{2, 1, 1} {
+		moveTo(i*100+50, y+y/2)
+		press(MouseLeft)
+		frame()
+		release(MouseLeft)
+		before := selected
+		frame()
+		if selected != i {
+			t.Errorf("clicking option %v selected %v", i, selected)
+		}
+		if changed[1] != (before != i) {
+			t.Errorf("clicking option %v again: RadioGroup returned %v", i, changed[1])
+		}
+	}
+
+	// the click gives it the focus, then Space toggles it
+	clickAt(150, y/2, frame)
+	pressKey(Space, 0)
+	frame()
+	if checked {
+		t.Errorf("Space on the focused checkbox didn't toggle it back")
+	}
+}
+
+func TestSliderAndDrag(t *testing.T) {
+	testWindow(t, 200, 100)
+	volume, count := 0.0, 10
+	frame := func() {
+		Layout(0, Vertical, image.Rect(0, 0, 200, 100))
+		SliderFloat(0, "volume", &volume, 0, 1, nil)
+		DragInt(1, "count", &count, 0.5, 0, 100, nil)
+		DrawUi()
+	}
+	frame()
+	h := widgetHeight(ui_frame.DefaultTheme.FontFace)
+	near := func(got, want float64) bool { return math.Abs(got-want) < 0.02 }
+
+	// the slider jumps to the click and follows the mouse while it is held
+	moveTo(150, h/2)
+	press(MouseLeft)
+	frame()
+	if !near(volume, 0.75) {
+		t.Errorf("the slider clicked at 3/4 is at %v", volume)
+	}
+	moveTo(50, 90) // off the slider, it still follows
+	frame()
+	release(MouseLeft)
+	frame()
+	moveTo(190, h/2)
+	frame()
+	if !near(volume, 0.25) {
+		t.Errorf("the slider dragged to 1/4 is at %v", volume)
+	}
+
+	// it has the focus from the click
+	pressKey(Right, 0)
+	frame()
+	if !near(volume, 0.26) {
+		t.Errorf("Right moved the slider to %v", volume)
+	}
+	pressKey(End, 0)
+	frame()
+	if volume != 1 {
+		t.Errorf("End moved the slider to %v", volume)
+	}
+
+	// the drag number changes by the speed per pixel, from where it was
+	y := h + ui_frame.Layouts[0].Style.Spacing + h/2
+	moveTo(100, y)
+	press(MouseLeft)
+	frame()
+	if count != 10 {
+		t.Errorf("clicking the drag number changed it to %v", count)
+	}
+	moveTo(140, y)
+	frame()
+	if count != 30 {
+		t.Errorf("dragging 40 pixels at 0.5 gave %v, want 30", count)
+	}
+	moveTo(400, y)
+	frame()
+	if count != 100 {
+		t.Errorf("the drag number went over its max to %v", count)
+	}
+	release(MouseLeft)
+	frame()
+	moveTo(0, y)
+	frame()
+	if count != 100 {
+		t.Errorf("the drag number changed after the release to %v", count)
+	}
+}
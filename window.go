@@ -0,0 +1,434 @@
+/*
+   A Window owns everything that belongs to one window on the screen:
+   the backend (and with it the gl context), the image we compose into,
+   the draw queue, the event stream, the input state and the Ui.
+
+   The package level functions (ToDraw, Draw, Events, TextButton, ...)
+   work on the current window. Create() makes its window the current one,
+   more windows can be opened with NewWindow() and switched to with MakeCurrent():
+
+       tomato.Create(1080, 720, "Editor")
+       inspector, _ := tomato.NewWindow(tomato.Options{Width: 300, Height: 600, Title: "Inspector"})
+       editor := tomato.Current()
+
+       for editor.Alive() {
+           editor.MakeCurrent()
+           ... draw the editor
+           inspector.MakeCurrent()
+           ... draw the inspector
+       }
+*/
+
+package tomato
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+	"sync"
+)
+
+type Window struct {
+	backend Backend
+
+	// Direct access to the glfw.Window, nil for other backends
+	Win *glfwWindow
+	// we compose everything for the frame into this image
+	Img *image.RGBA
+
+	// input state
+	MouseX, MouseY                     int
+	MouseDownL, MouseDownM, MouseDownR bool
+	Mods                               Mods // of the last key or mouse button event
+	Pads                               [MAX_PADS]PadState
+
+	clearColor color.RGBA
+
+	// @Memory prealocate memory maybe?
+	drawQueue []drawOp
+	drawLock  sync.Mutex
+	clips     []image.Rectangle // PushClip() stack
+	layer     int               // of the next ToDraw, bigger is drawn later (panels)
+
+	// dirty rectangles, see dirty.go
+	lastQueue []drawOp          // of the last frame
+	composed  bool              // Img is lastQueue composed over nothing
+	presented bool              // the backend has Img
+	cleared   bool              // Clear() was called since the last Draw()
+	invalid   []image.Rectangle // Invalidate()
+	onDraw    func(DrawStats)
+
+	dead      bool
+	destroyed bool
+	inEvents  chan Ev
+	outEvents chan Ev
+
+	frame uint64 // incremented by every call to Alive()
+	rec   recording
+
+	ui Ui_Frame
+}
+
+// the window the package level functions work on
+var current *Window
+
+// the Backend of the windows made by Create(), nil for gl
+var backend Backend
+
+// Select the Backend used by Create(), call it before Create()
+// A Backend draws one window, so don't share it between windows.
+func UseBackend(b Backend) {
+	backend = b
+}
+
+// Opens another window with the gl backend (Software with the headless build tag).
+// It doesn't become the current window.
+func NewWindow(opts Options) (*Window, error) {
+	return NewWindowWithBackend(newDefaultBackend(), opts)
+}
+
+func NewWindowWithBackend(b Backend, opts Options) (*Window, error) {
+	w := &Window{
+		backend:    b,
+		clearColor: defaultClearColor,
+	}
+	if opts.ClearColor != nil {
+		w.clearColor = color.RGBAModel.Convert(opts.ClearColor).(color.RGBA)
+	}
+	w.eventsSetup()
+	if err := b.Create(w, opts); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// The window the package level functions work on
+func Current() *Window {
+	return current
+}
+
+// Makes the package level functions and the gl context work on w
+func (w *Window) MakeCurrent() {
+	current = w
+	ui_frame = &w.ui
+	w.makeContextCurrent()
+	w.syncGlobals()
+}
+
+// the package level variables mirror the current window
+func (w *Window) syncGlobals() {
+	if w != current {
+		return
+	}
+	Win = w.Win
+	GuiImg = w.Img
+	MouseX, MouseY = w.MouseX, w.MouseY
+	MouseDownL, MouseDownM, MouseDownR = w.MouseDownL, w.MouseDownM, w.MouseDownR
+	w.syncGLGlobals()
+}
+
+// false once the window was closed or Die() was called, the window is destroyed then
+func (w *Window) Alive() bool {
+	if w.destroyed {
+		return false
+	}
+	w.frame++
+	if !w.dead && w.backend.Alive() {
+		w.replay()
+		return true
+	} else {
+		w.Destroy()
+		return false
+	}
+}
+
+func (w *Window) Die() {
+	w.dead = true
+}
+
+func (w *Window) Destroy() {
+	if w.destroyed {
+		return
+	}
+	w.destroyed = true
+	w.ui.saveFile()
+	w.backend.Destroy()
+}
+
+func (w *Window) Events() <-chan Ev {
+	return w.outEvents
+}
+
+// function adapted from faiface/gui
+func (w *Window) eventsSetup() {
+
+	w.inEvents = make(chan Ev)
+	w.outEvents = make(chan Ev)
+
+	inEvents, outEvents := w.inEvents, w.outEvents
+
+	go func() {
+
+		var queue []Ev
+
+		for {
+			in, success := <-inEvents
+			if !success {
+				close(outEvents)
+				return
+			}
+			queue = append(queue, in)
+
+			for len(queue) > 0 {
+				select {
+				case outEvents <- queue[0]:
+					queue = queue[1:]
+				case in, success := <-inEvents:
+					if !success {
+						for _, in := range queue {
+							outEvents <- in
+						}
+						close(outEvents)
+						return
+					}
+					queue = append(queue, in)
+				}
+			}
+		}
+	}()
+}
+
+// Feed an event into Events(), as if it came from the window.
+// The input state (MouseX, MouseDownL, Mods, ...) is updated accordingly, so the Ui reacts to it too.
+// Useful to script input, see also Replay().
+func Inject(ev Ev) {
+	current.Inject(ev)
+}
+
+func (w *Window) Inject(ev Ev) {
+	switch ev.Kind {
+	case MouMove:
+		w.MouseX, w.MouseY = ev.X, ev.Y
+	case KeyUp:
+		w.Mods = ev.Mods
+	case KeyDown, KeyRepeat:
+		w.Mods = ev.Mods
+		w.ui.pushKeyboard(ev)
+	case RuneTyped, MouScroll:
+		w.ui.pushKeyboard(ev)
+	case MouDown, MouUp:
+		w.Mods = ev.Mods
+		down := ev.Kind == MouDown
+		switch ev.Button {
+		case MouseLeft:
+			w.MouseDownL = down
+		case MouseMiddle:
+			w.MouseDownM = down
+		case MouseRight:
+			w.MouseDownR = down
+		}
+	case PadConnect, PadDisconnect, PadDown, PadUp, PadMove:
+		w.updatePad(ev)
+	}
+	w.syncGlobals()
+
+	w.record(ev)
+	w.inEvents <- ev
+}
+
+// Called by the Backend when the size of the window changed.
+// Reallocates Img, moves the anchored layouts and sends a WinResize event.
+func (w *Window) resized(width, height int) {
+	w.drawLock.Lock()
+	old := w.Img.Bounds().Size()
+	w.Img = image.NewRGBA(image.Rect(0, 0, width, height))
+	w.composed, w.presented = false, false
+	w.drawLock.Unlock()
+	w.syncGlobals()
+
+	w.ui.reanchor(old, Size{width, height})
+
+	w.Inject(Ev{
+		Kind:  WinResize,
+		Point: image.Pt(width, height),
+	})
+}
+
+type drawOp struct {
+	where image.Rectangle
+	img   image.Image
+	src   image.Point // of img that goes to where.Min
+	layer int
+	op    draw.Op
+	text  *textRun // instead of img, see DrawText
+
+	// set by Draw
+	gpu  bool   // text drawn by the gpu, it isn't in Img
+	hash uint64 // of the pixels, see sameOp
+}
+
+// Queues img to be drawn into r, image.ZP of img goes to r.Min.
+// Only the part in the clip rectangle (see PushClip) is drawn.
+func (w *Window) ToDraw(r image.Rectangle, img image.Image) {
+	w.toDraw(r, img, draw.Src)
+}
+
+// like ToDraw, but blends img over what is there
+func (w *Window) toDrawOver(r image.Rectangle, img image.Image) {
+	w.toDraw(r, img, draw.Over)
+}
+
+func (w *Window) toDraw(r image.Rectangle, img image.Image, op draw.Op) {
+	clipped := r.Intersect(w.ClipRect())
+	if clipped.Empty() {
+		return
+	}
+	w.drawLock.Lock()
+	w.drawQueue = append(w.drawQueue, drawOp{
+		where: clipped,
+		img:   img,
+		src:   clipped.Min.Sub(r.Min),
+		layer: w.layer,
+		op:    op,
+	})
+	w.drawLock.Unlock()
+}
+
+func (w *Window) queueText(r image.Rectangle, text *textRun) {
+	clipped := r.Intersect(w.ClipRect())
+	if clipped.Empty() {
+		return
+	}
+	w.drawLock.Lock()
+	w.drawQueue = append(w.drawQueue, drawOp{
+		where: clipped,
+		layer: w.layer,
+		text:  text,
+	})
+	w.drawLock.Unlock()
+}
+
+// Everything queued with ToDraw until PopClip() is cut to r (and the clip rectangles before)
+func (w *Window) PushClip(r image.Rectangle) {
+	w.clips = append(w.clips, r.Intersect(w.ClipRect()))
+}
+
+func (w *Window) PopClip() {
+	if len(w.clips) == 0 {
+		panic("\ntomato ERROR: PopClip() without PushClip(...)\n")
+	}
+	w.clips = w.clips[:len(w.clips)-1]
+}
+
+// The current clip rectangle, the whole window if there is none
+func (w *Window) ClipRect() image.Rectangle {
+	if len(w.clips) == 0 {
+		return w.Img.Bounds()
+	}
+	return w.clips[len(w.clips)-1]
+}
+
+// The next frame starts from nothing, instead of over the last one.
+// Img isn't cleared right away, Draw() only clears what changed.
+func (w *Window) Clear() {
+	w.drawLock.Lock()
+	w.cleared = true
+	w.drawLock.Unlock()
+	w.backend.Clear()
+}
+
+func (w *Window) Draw() {
+	w.drawLock.Lock()
+
+	// the panels in front go last
+	sort.SliceStable(w.drawQueue, func(i, j int) bool {
+		return w.drawQueue[i].layer < w.drawQueue[j].layer
+	})
+
+	bounds := w.Img.Bounds()
+	gpu, gpuText := w.backend.(glyphBackend)
+	for i := range w.drawQueue {
+		op := &w.drawQueue[i]
+		op.gpu = gpuText && op.text != nil && !coveredLater(w.drawQueue[i+1:], op.where.Intersect(bounds))
+		op.hash = opHash(*op)
+	}
+
+	var dirty []image.Rectangle
+	switch {
+	case !w.cleared:
+		// over the last frame, only the ops change it
+		for _, op := range w.drawQueue {
+			if !op.gpu {
+				dirty = append(dirty, op.where)
+			}
+		}
+	case !w.composed:
+		dirty = []image.Rectangle{bounds}
+	default:
+		dirty = diffQueues(w.lastQueue, w.drawQueue)
+	}
+	dirty = mergeRects(append(dirty, w.invalid...), bounds)
+
+	if w.cleared {
+		// the dirty rectangles from nothing
+		for _, d := range dirty {
+			draw.Draw(w.Img, d, image.Transparent, image.ZP, draw.Src)
+			w.compose(d)
+		}
+	} else {
+		w.compose(bounds)
+	}
+
+	var onTop []drawOp // text for the gpu
+	for _, op := range w.drawQueue {
+		if op.gpu {
+			op.where = op.where.Intersect(bounds)
+			onTop = append(onTop, op)
+		}
+	}
+	if gpuText {
+		gpu.drawGlyphs(onTop)
+	}
+
+	stats := DrawStats{Ops: len(w.drawQueue), Dirty: dirty}
+	if p, ok := w.backend.(dirtyBackend); ok && w.presented {
+		p.presentDirty(w.Img, dirty)
+	} else {
+		// the whole frame, the backend doesn't have the last one
+		w.backend.Present(w.Img)
+		stats.Dirty = []image.Rectangle{bounds}
+	}
+	for _, d := range stats.Dirty {
+		stats.Uploaded += 4 * d.Dx() * d.Dy()
+	}
+
+	// the queue of this frame is the last one now, the draw queue starts empty
+	w.lastQueue, w.drawQueue = w.drawQueue, w.lastQueue[:0]
+	// frames drawn over the last one may have anything in Img
+	w.composed, w.presented = w.cleared, true
+	w.cleared = false
+	w.invalid = w.invalid[:0]
+	onDraw := w.onDraw
+	w.drawLock.Unlock()
+
+	if onDraw != nil {
+		onDraw(stats)
+	}
+}
+
+// draws the ops that aren't on the gpu into Img, inside clip
+func (w *Window) compose(clip image.Rectangle) {
+	for _, op := range w.drawQueue {
+		where := op.where.Intersect(clip)
+		if op.gpu || where.Empty() {
+			continue
+		}
+		if op.text != nil {
+			atlas.draw(w.Img, op.text.glyphs, image.NewUniform(op.text.color), where)
+			continue
+		}
+		src := op.src.Add(where.Min.Sub(op.where.Min))
+		draw.Draw(w.Img, where, op.img, src, op.op)
+	}
+}
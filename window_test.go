@@ -0,0 +1,138 @@
+package tomato
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+// A Software window with a fresh Ui for a test, it is the current one until the test is done
+func testWindow(t *testing.T, width, height int) *Window {
+	t.Helper()
+	w, err := NewWindowWithBackend(&Software{}, Options{Width: width, Height: height})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := current
+	w.MakeCurrent()
+	SetupUi()
+	t.Cleanup(func() {
+		w.Destroy()
+		if before != nil {
+			before.MakeCurrent()
+		} else {
+			current, ui_frame = nil, nil
+		}
+	})
+	return w
+}
+
+// input for the current window, like the backend would send it
+func moveTo(x, y int) {
+	Inject(Ev{Kind: MouMove, Point: image.Pt(x, y)})
+}
+
+func press(b Button) {
+	Inject(Ev{Kind: MouDown, Point: image.Pt(current.MouseX, current.MouseY), Button: b})
+}
+
+func release(b Button) {
+	Inject(Ev{Kind: MouUp, Point: image.Pt(current.MouseX, current.MouseY), Button: b})
+}
+
+func pressKey(k Key, mods Mods) {
+	Inject(Ev{Kind: KeyDown, Key: k, Mods: mods})
+	Inject(Ev{Kind: KeyUp, Key: k, Mods: mods})
+}
+
+func typeText(s string) {
+	for _, r := range s {
+		Inject(Ev{Kind: RuneTyped, Rune: r})
+	}
+}
+
+// every window has its own input and Ui, the package level functions follow MakeCurrent
+func TestWindowsApart(t *testing.T) {
+	a := testWindow(t, 100, 100)
+	b := testWindow(t, 200, 50)
+	if Current() != b || GuiImg != b.Img {
+		t.Fatalf("the last window isn't the current one")
+	}
+
+	moveTo(10, 20)
+	press(MouseLeft)
+	if b.MouseX != 10 || !b.MouseDownL || MouseX != 10 {
+		t.Errorf("the input didn't go to the current window")
+	}
+	if a.MouseX != 0 || a.MouseDownL {
+		t.Errorf("the input of one window went to the other")
+	}
+
+	a.MakeCurrent()
+	if GuiImg != a.Img || GuiImg.Bounds().Dx() != 100 || MouseX != 0 || MouseDownL {
+		t.Errorf("the globals don't follow MakeCurrent")
+	}
+
+	// a button clicked in b, a has nothing of it
+	frame := func(w *Window) ButtonState {
+		w.MakeCurrent()
+		Layout(0, Vertical, image.Rect(0, 0, 100, 50))
+		state := TextButtonState(0, "button", nil)
+		DrawUi()
+		return state
+	}
+	frame(a)
+	frame(b)
+	b.Inject(Ev{Kind: MouUp, Point: image.Pt(10, 20), Button: MouseLeft})
+	if frame(a).Clicked {
+		t.Errorf("the click in the other window clicked the button")
+	}
+	if !frame(b).Clicked {
+		t.Errorf("the button of the window wasn't clicked")
+	}
+	if b.ui.focus == 0 || a.ui.focus != 0 {
+		t.Errorf("the focus went to the wrong window")
+	}
+}
+
+// a resize gives a new Img, moves the anchored layouts and sends WinResize
+func TestResize(t *testing.T) {
+	w := testWindow(t, 200, 100)
+	frame := func() bool {
+		Layout(0, Vertical, image.Rect(100, 50, 200, 100))
+		AnchorLayout(AnchorBottomRight)
+		clicked := TextButton(0, "ok", nil)
+		DrawUi()
+		return clicked
+	}
+	frame()
+
+	w.backend.(*Software).Resize(300, 200)
+	if w.Img.Bounds() != image.Rect(0, 0, 300, 200) || GuiImg != w.Img {
+		t.Errorf("the image is %v after the resize to 300x200", w.Img.Bounds())
+	}
+	select {
+	case ev := <-Events():
+		if ev.Kind != WinResize || ev.Point != image.Pt(300, 200) {
+			t.Errorf("the resize sent %v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("the resize didn't send an event")
+	}
+	if got := ui_frame.Layouts[0].Place; got != image.Rect(200, 150, 300, 200) {
+		t.Errorf("the anchored layout is at %v, want it to stay at the bottom right", got)
+	}
+
+	// the button is where it was drawn, and there it is clicked
+	frame()
+	if got := w.backend.(*Software).Frame().Bounds(); got != w.Img.Bounds() {
+		t.Errorf("the presented frame is %v", got)
+	}
+	moveTo(250, 160)
+	press(MouseLeft)
+	frame()
+	release(MouseLeft)
+	if !frame() {
+		t.Errorf("the button of the anchored layout wasn't clicked")
+	}
+}